@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"picshow/internal/cache"
+	"picshow/internal/config"
+	"picshow/internal/files"
+	"picshow/internal/kv"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	failuresCmd.AddCommand(failuresRetryCmd)
+	failuresCmd.AddCommand(failuresIgnoreCmd)
+	rootCmd.AddCommand(failuresCmd)
+}
+
+// failure is how the API reports a failed file
+type failure struct {
+	Filename   string
+	Error      string
+	Stderr     string
+	Attempts   uint32
+	LastFailed time.Time
+	NextRetry  time.Time
+	Ignored    bool
+}
+
+type failureResult struct {
+	Filename string
+	Error    string
+}
+
+var failuresCmd = &cobra.Command{
+	Use:   "failures",
+	Short: "List the files that couldn't be indexed",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadCommandConfig()
+		var failures []failure
+		if checkServerRunning(cfg.PORT) {
+			if err := callAPI(http.MethodGet, cfg.PORT, "/api/failures", nil, &failures); err != nil {
+				log.WithError(err).Fatal("Failed to fetch failures")
+			}
+		} else {
+			withRepository(cfg, func(repo *kv.Repository) {
+				protoFailures, err := repo.GetFailures()
+				if err != nil {
+					log.WithError(err).Fatal("Failed to fetch failures")
+				}
+				for _, f := range protoFailures {
+					failures = append(failures, failure{
+						Filename:   f.Filename,
+						Error:      f.Error,
+						Stderr:     f.Stderr,
+						Attempts:   f.Attempts,
+						LastFailed: f.LastFailed.AsTime(),
+						NextRetry:  f.NextRetry.AsTime(),
+						Ignored:    f.Ignored,
+					})
+				}
+			})
+		}
+		printFailures(failures)
+	},
+}
+
+var failuresRetryCmd = &cobra.Command{
+	Use:   "retry <file>...",
+	Short: "Retry failed files, right away when the server is running or on the next scan",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateFailures(args, "/api/failures/retry", files.ResetFailure)
+	},
+}
+
+var failuresIgnoreCmd = &cobra.Command{
+	Use:   "ignore <file>...",
+	Short: "Skip failed files in future scans until they are retried",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateFailures(args, "/api/failures/ignore", files.IgnoreFailure)
+	},
+}
+
+func loadCommandConfig() *config.Config {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load config")
+	}
+	setLoggingFromConfig(cfg)
+	return cfg
+}
+
+// updateFailures goes through the server when it runs since it holds the database
+func updateFailures(filenames []string, path string, offline func(*kv.Repository, string) error) {
+	cfg := loadCommandConfig()
+	var results []failureResult
+	if checkServerRunning(cfg.PORT) {
+		body := map[string][]string{"filenames": filenames}
+		if err := callAPI(http.MethodPost, cfg.PORT, path, body, &results); err != nil {
+			log.WithError(err).Fatal("Failed to update failures")
+		}
+	} else {
+		withRepository(cfg, func(repo *kv.Repository) {
+			for _, filename := range filenames {
+				result := failureResult{Filename: filename}
+				if err := offline(repo, filename); err != nil {
+					result.Error = err.Error()
+				}
+				results = append(results, result)
+			}
+		})
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Printf("%s: %s\n", result.Filename, result.Error)
+		} else {
+			fmt.Printf("%s: done\n", result.Filename)
+		}
+	}
+}
+
+func withRepository(cfg *config.Config, fn func(*kv.Repository)) {
+	db, err := kv.GetDB(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open database")
+	}
+	runtimeCache, err := cache.NewCache(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to create cache")
+	}
+	repo := kv.NewRepository(db, runtimeCache, cfg)
+	defer repo.Close()
+	fn(repo)
+}
+
+func callAPI(method string, port int, path string, body, response interface{}) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", port, path), &payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+func printFailures(failures []failure) {
+	if len(failures) == 0 {
+		fmt.Println("No failed files.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tATTEMPTS\tLAST FAILED\tNEXT RETRY\tERROR")
+	for _, f := range failures {
+		nextRetry := f.NextRetry.Local().Format(time.DateTime)
+		if f.Ignored {
+			nextRetry = "ignored"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", f.Filename, f.Attempts, f.LastFailed.Local().Format(time.DateTime), nextRetry, f.Error)
+	}
+	w.Flush()
+	for _, f := range failures {
+		if f.Stderr != "" {
+			fmt.Printf("\n%s:\n%s\n", f.Filename, f.Stderr)
+		}
+	}
+}
@@ -7,10 +7,13 @@ import (
 	"os/signal"
 	"picshow/internal/cache"
 	"picshow/internal/config"
+	"picshow/internal/diskcache"
 	"picshow/internal/files"
 	"picshow/internal/kv"
 	kvdb "picshow/internal/kv"
+	"picshow/internal/rendition"
 	"picshow/internal/server"
+	"picshow/internal/transcode"
 	"picshow/internal/utils"
 	"runtime/debug"
 	"sync"
@@ -84,16 +87,40 @@ func serve() {
 		log.Fatalf("Error creating KV repository: %v", err)
 	}
 
+	displayCache, err := diskcache.New(runtimeConfig.DisplayCachePath, runtimeConfig.DisplayCacheSizeMB)
+	if err != nil {
+		log.Fatalf("Error creating display cache: %v", err)
+	}
+	display := rendition.NewDisplay(displayCache)
+
+	renditionCache, err := diskcache.New(runtimeConfig.RenditionCachePath, runtimeConfig.RenditionCacheSizeMB)
+	if err != nil {
+		log.Fatalf("Error creating rendition cache: %v", err)
+	}
+	resized := rendition.NewResized(renditionCache)
+
+	previewCache, err := diskcache.New(runtimeConfig.PreviewCachePath, runtimeConfig.PreviewCacheSizeMB)
+	if err != nil {
+		log.Fatalf("Error creating preview cache: %v", err)
+	}
+	preview := rendition.NewPreview(previewCache, runtimeConfig.MaxThumbnailSize, runtimeConfig.PreviewSeconds)
+
 	// Create a context that we can cancel
 	ctx, cancel := context.WithCancel(context.Background())
 
 	var wg sync.WaitGroup
 
 	repo := kvdb.NewRepository(kv, runtimeCache, runtimeConfig)
+	if err := repo.MigrateThumbnails(); err != nil {
+		log.Fatalf("Error migrating thumbnails: %v", err)
+	}
 
 	// Create a channel to signal when to start the shutdown process
 	shutdownChan := make(chan struct{})
 
+	// The processor is shared with the server which ingests uploads through it
+	processor := files.NewProcessor(runtimeConfig, repo, display, runtimeConfig.BatchSize, runtimeConfig.Concurrency)
+
 	// Start periodic file processing
 	wg.Add(1)
 	go func() {
@@ -104,11 +131,17 @@ func serve() {
 				shutdownChan <- struct{}{}
 			}
 		}()
-		runProcessor(ctx, runtimeConfig, repo, runtimeConfig.RefreshInterval, kv)
+		runProcessor(ctx, runtimeConfig, processor, runtimeConfig.RefreshInterval, kv)
 	}()
 
+	transcodeCache, err := diskcache.New(runtimeConfig.TranscodeCachePath, runtimeConfig.TranscodeCacheSizeMB)
+	if err != nil {
+		log.Fatalf("Error creating transcode cache: %v", err)
+	}
+	transcoder := transcode.NewTranscoder(transcodeCache)
+
 	// Start the web server
-	srv := server.NewServer(runtimeConfig, repo, runtimeCache)
+	srv := server.NewServer(runtimeConfig, repo, runtimeCache, processor, transcoder, display, resized, preview)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -191,15 +224,23 @@ func gracefulShutdown(
 	}
 }
 
-func runProcessor(ctx context.Context, runtimeConfig *config.Config, repo *kvdb.Repository, refreshInterval int, db *badger.DB) {
+func runProcessor(ctx context.Context, runtimeConfig *config.Config, processor *files.Processor, refreshInterval int, db *badger.DB) {
+	// A scan that comes up outside of the scan window runs when the window opens instead
+	var windowOpens <-chan time.Time
+
 	runProcessorOnce := func() {
 		log.Info("Starting file processing...")
 
-		processor := files.NewProcessor(runtimeConfig, repo, runtimeConfig.BatchSize, runtimeConfig.Concurrency)
 		err := processor.Process(ctx)
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				log.Info("File processing canceled.")
+			} else if errors.Is(err, files.ErrScanRunning) {
+				log.Info("File processing is already running.")
+			} else if errors.Is(err, files.ErrOutsideScanWindow) {
+				next := processor.Throttle().NextWindow(time.Now())
+				log.Infof("File processing postponed until %s.", next.Format(time.DateTime))
+				windowOpens = time.After(time.Until(next))
 			} else {
 				log.Errorf("Error processing files: %v", err)
 			}
@@ -222,6 +263,11 @@ func runProcessor(ctx context.Context, runtimeConfig *config.Config, repo *kvdb.
 			return
 		case <-ticker.C:
 			runProcessorOnce()
+		case <-windowOpens:
+			runProcessorOnce()
+		case <-processor.ScanRequests():
+			log.Info("Rescan requested")
+			runProcessorOnce()
 		}
 	}
 }
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"picshow/internal/diskcache"
+	"picshow/internal/files"
+	"picshow/internal/kv"
+	"picshow/internal/rendition"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// regenerationPollInterval is how often the progress of a regeneration is printed
+const regenerationPollInterval = 2 * time.Second
+
+var (
+	regenerateAll          bool
+	regenerateType         string
+	regenerateSizeMismatch bool
+	regenerateIDs          []uint
+)
+
+func init() {
+	thumbnailsRegenerateCmd.Flags().BoolVar(&regenerateAll, "all", false, "Regenerate the thumbnails of every file")
+	thumbnailsRegenerateCmd.Flags().StringVar(&regenerateType, "type", "", "Only regenerate the thumbnails of this type of file (image, video or audio)")
+	thumbnailsRegenerateCmd.Flags().BoolVar(&regenerateSizeMismatch, "size-mismatch", false, "Only regenerate the thumbnails that don't match MaxThumbnailSize")
+	thumbnailsRegenerateCmd.Flags().UintSliceVar(&regenerateIDs, "ids", nil, "Only regenerate the thumbnails of these file IDs, comma-separated")
+	thumbnailsCmd.AddCommand(thumbnailsRegenerateCmd)
+	rootCmd.AddCommand(thumbnailsCmd)
+}
+
+var thumbnailsCmd = &cobra.Command{
+	Use:   "thumbnails",
+	Short: "Manage the thumbnails of indexed files",
+}
+
+var thumbnailsRegenerateCmd = &cobra.Command{
+	Use:   "regenerate",
+	Short: "Make the thumbnails of indexed files again, through the server when it is running",
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := files.RegenerateFilter{Type: regenerateType, SizeMismatch: regenerateSizeMismatch}
+		for _, id := range regenerateIDs {
+			filter.IDs = append(filter.IDs, uint64(id))
+		}
+		if !regenerateAll && filter.Type == "" && !filter.SizeMismatch && len(filter.IDs) == 0 {
+			log.Fatal("Pick the files with --all, --type, --size-mismatch or --ids")
+		}
+		if err := filter.Validate(); err != nil {
+			log.WithError(err).Fatal("Invalid filter")
+		}
+
+		cfg := loadCommandConfig()
+		if checkServerRunning(cfg.PORT) {
+			var status files.RegenerationStatus
+			if err := callAPI(http.MethodPost, cfg.PORT, "/api/thumbnails/regenerate", filter, &status); err != nil {
+				log.WithError(err).Fatal("Failed to start thumbnail regeneration")
+			}
+			status = waitForRegeneration(func() files.RegenerationStatus {
+				var status files.RegenerationStatus
+				if err := callAPI(http.MethodGet, cfg.PORT, "/api/thumbnails/regenerate", nil, &status); err != nil {
+					log.WithError(err).Fatal("Failed to fetch regeneration progress")
+				}
+				return status
+			})
+			printRegenerationResult(status)
+			return
+		}
+
+		displayCache, err := diskcache.New(cfg.DisplayCachePath, cfg.DisplayCacheSizeMB)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create display cache")
+		}
+		withRepository(cfg, func(repo *kv.Repository) {
+			processor := files.NewProcessor(cfg, repo, rendition.NewDisplay(displayCache), cfg.BatchSize, cfg.Concurrency)
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			done := make(chan error, 1)
+			go func() { done <- processor.RegenerateThumbnails(ctx, filter) }()
+			status := waitForRegeneration(processor.RegenerationStatus)
+			if err := <-done; err != nil {
+				log.WithError(err).Error("Thumbnail regeneration stopped")
+			}
+			printRegenerationResult(status)
+		})
+	},
+}
+
+// waitForRegeneration prints the progress of a regeneration until it is done and returns how it ended
+func waitForRegeneration(poll func() files.RegenerationStatus) files.RegenerationStatus {
+	ticker := time.NewTicker(regenerationPollInterval)
+	defer ticker.Stop()
+	for {
+		status := poll()
+		if !status.Running && status.StartedAt != nil {
+			return status
+		}
+		if status.Total > 0 {
+			printRegenerationProgress(status)
+		}
+		<-ticker.C
+	}
+}
+
+func printRegenerationResult(status files.RegenerationStatus) {
+	if status.LastError != "" {
+		fmt.Printf("Regeneration stopped: %s\n", status.LastError)
+	}
+	printRegenerationProgress(status)
+}
+
+// printRegenerationProgress counts the files that were done, whether they failed or not
+func printRegenerationProgress(status files.RegenerationStatus) {
+	fmt.Printf("Processed %d of %d files, %d failed\n", status.Processed, status.Total, status.Failed)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/viper"
 )
@@ -19,8 +20,69 @@ type Config struct {
 	CacheSizeMB      int
 	LogLevel         string
 	BackupFolderPath string
+	// TranscodeCachePath holds the HLS renditions of videos browsers can't play natively
+	TranscodeCachePath   string
+	TranscodeCacheSizeMB int
+	// DisplayCachePath holds the JPEG renditions of HEIC, AVIF and RAW images
+	DisplayCachePath   string
+	DisplayCacheSizeMB int
+	// RenditionCachePath holds the downscaled images served to small screens
+	RenditionCachePath   string
+	RenditionCacheSizeMB int
+	// PreviewCachePath holds the looped previews of videos and animated images the grid autoplays
+	PreviewCachePath   string
+	PreviewCacheSizeMB int
+	// PreviewSeconds is how long video previews are
+	PreviewSeconds int
+	// UploadFolder is the subfolder of FolderPath uploads are written to, the library root when empty
+	UploadFolder string
+	// DuplicatePolicy is what scans do with copies of indexed files, one of the DuplicatePolicy constants
+	DuplicatePolicy string
+	// DuplicatesFolderPath is where the move policy puts copies
+	DuplicatesFolderPath string
+	// MaxFailedAttempts is how many times a file that fails to index is retried before it is skipped
+	MaxFailedAttempts int
+	// IncludePatterns are globs matched against file names and paths relative to FolderPath,
+	// when set only matching files are indexed
+	IncludePatterns []string
+	// ExcludePatterns are globs for files that are never indexed
+	ExcludePatterns []string
+	// Extensions limits indexing to files with these extensions, any extension when empty
+	Extensions []string
+	// MinFileSize in bytes, smaller files are skipped
+	MinFileSize int64
+	// IncludeHidden indexes files and folders whose name starts with a dot
+	IncludeHidden bool
+	// MaxDepth is how many folder levels below each scan root are indexed, 1 for the root only, -1 for no limit
+	MaxDepth int
+	// FollowSymlinks indexes the files and folders symlinks point to, symlinks are skipped otherwise
+	FollowSymlinks bool
+	// UseFd lists files with fd when it is installed, which is faster on large libraries
+	UseFd bool
+	// ToolNiceness is the nice level scan tools run at, they also get the lowest best-effort
+	// I/O priority. -1 runs them at normal priority.
+	ToolNiceness int
+	// RequestPauseSeconds pauses scans while the web UI is used and for that many seconds after, -1 never pauses
+	RequestPauseSeconds int
+	// MaxLoadAverage pauses scans while the one minute load average is above it, 0 never pauses
+	MaxLoadAverage float64
+	// MaxFilesPerMinute limits how fast scans process files, 0 for no limit
+	MaxFilesPerMinute int
+	// ScanWindow restricts scans to a daily time range like 01:00-06:00, scans run at any time when empty
+	ScanWindow string
 }
 
+const (
+	// DuplicatesMove moves copies out of the library into DuplicatesFolderPath
+	DuplicatesMove = "move"
+	// DuplicatesIndex leaves copies in place and records them against the indexed file
+	DuplicatesIndex = "index"
+	// DuplicatesHardlink replaces copies with hard links to the indexed file
+	DuplicatesHardlink = "hardlink"
+	// DuplicatesDelete removes copies
+	DuplicatesDelete = "delete"
+)
+
 const DefaultPort = 8281
 
 func GetPort() int {
@@ -52,10 +114,66 @@ func LoadConfig() (*Config, error) {
 	if err := v.Unmarshal(&config); err != nil {
 		return nil, err
 	}
+	config.applyDefaults()
 	return &config, nil
 }
 
+// applyDefaults fills the settings that the first-run wizard doesn't ask for
+// so that configs written by older versions keep working
+func (c *Config) applyDefaults() {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	if c.TranscodeCachePath == "" {
+		c.TranscodeCachePath = filepath.Join(cacheDir, "picshow", "transcode")
+	}
+	if c.TranscodeCacheSizeMB == 0 {
+		c.TranscodeCacheSizeMB = 2048
+	}
+	if c.DisplayCachePath == "" {
+		c.DisplayCachePath = filepath.Join(cacheDir, "picshow", "display")
+	}
+	if c.DisplayCacheSizeMB == 0 {
+		c.DisplayCacheSizeMB = 1024
+	}
+	if c.RenditionCachePath == "" {
+		c.RenditionCachePath = filepath.Join(cacheDir, "picshow", "renditions")
+	}
+	if c.RenditionCacheSizeMB == 0 {
+		c.RenditionCacheSizeMB = 1024
+	}
+	if c.PreviewCachePath == "" {
+		c.PreviewCachePath = filepath.Join(cacheDir, "picshow", "previews")
+	}
+	if c.PreviewCacheSizeMB == 0 {
+		c.PreviewCacheSizeMB = 512
+	}
+	if c.PreviewSeconds == 0 {
+		c.PreviewSeconds = 3
+	}
+	if c.DuplicatePolicy == "" {
+		c.DuplicatePolicy = DuplicatesMove
+	}
+	if c.DuplicatesFolderPath == "" && c.FolderPath != "" {
+		c.DuplicatesFolderPath = filepath.Join(filepath.Dir(c.FolderPath), "duplicates")
+	}
+	if c.MaxFailedAttempts == 0 {
+		c.MaxFailedAttempts = 5
+	}
+	if c.MaxDepth == 0 {
+		c.MaxDepth = 1
+	}
+	if c.ToolNiceness == 0 {
+		c.ToolNiceness = 10
+	}
+	if c.RequestPauseSeconds == 0 {
+		c.RequestPauseSeconds = 2
+	}
+}
+
 func (c *Config) Save() error {
+	c.applyDefaults()
 	v := viper.New()
 	v.SetDefault("PORT", GetPort())
 	v.SetConfigName("config")
@@ -82,5 +200,45 @@ func (c *Config) Save() error {
 	v.Set("Concurrency", c.Concurrency)
 	v.Set("LogLevel", c.LogLevel)
 	v.Set("BackupFolderPath", c.BackupFolderPath)
+	v.Set("TranscodeCachePath", c.TranscodeCachePath)
+	v.Set("TranscodeCacheSizeMB", c.TranscodeCacheSizeMB)
+	v.Set("DisplayCachePath", c.DisplayCachePath)
+	v.Set("DisplayCacheSizeMB", c.DisplayCacheSizeMB)
+	v.Set("RenditionCachePath", c.RenditionCachePath)
+	v.Set("RenditionCacheSizeMB", c.RenditionCacheSizeMB)
+	v.Set("PreviewCachePath", c.PreviewCachePath)
+	v.Set("PreviewCacheSizeMB", c.PreviewCacheSizeMB)
+	v.Set("PreviewSeconds", c.PreviewSeconds)
+	v.Set("UploadFolder", c.UploadFolder)
+	v.Set("DuplicatePolicy", c.DuplicatePolicy)
+	v.Set("DuplicatesFolderPath", c.DuplicatesFolderPath)
+	v.Set("MaxFailedAttempts", c.MaxFailedAttempts)
+	v.Set("IncludePatterns", c.IncludePatterns)
+	v.Set("ExcludePatterns", c.ExcludePatterns)
+	v.Set("Extensions", c.Extensions)
+	v.Set("MinFileSize", c.MinFileSize)
+	v.Set("IncludeHidden", c.IncludeHidden)
+	v.Set("MaxDepth", c.MaxDepth)
+	v.Set("FollowSymlinks", c.FollowSymlinks)
+	v.Set("UseFd", c.UseFd)
+	v.Set("ToolNiceness", c.ToolNiceness)
+	v.Set("RequestPauseSeconds", c.RequestPauseSeconds)
+	v.Set("MaxLoadAverage", c.MaxLoadAverage)
+	v.Set("MaxFilesPerMinute", c.MaxFilesPerMinute)
+	v.Set("ScanWindow", c.ScanWindow)
 	return v.SafeWriteConfig()
 }
+
+// UploadPath is the folder uploaded files are written to
+func (c *Config) UploadPath() string {
+	return filepath.Join(c.FolderPath, c.UploadFolder)
+}
+
+// ScanRoots lists the folders whose files are indexed
+func (c *Config) ScanRoots() []string {
+	roots := []string{c.FolderPath}
+	if uploadPath := c.UploadPath(); filepath.Clean(uploadPath) != filepath.Clean(c.FolderPath) {
+		roots = append(roots, uploadPath)
+	}
+	return roots
+}
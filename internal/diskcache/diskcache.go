@@ -0,0 +1,158 @@
+package diskcache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Cache is a size-bounded folder of generated files.
+// Every top-level entry (file or folder) is an item and the least recently
+// used items are removed first once the folder grows past its limit.
+// The size and last use of the items are read from the folder once, then kept up to date in memory.
+type Cache struct {
+	dir      string
+	maxBytes int64
+	mu       sync.Mutex
+	entries  map[string]*entry
+	total    int64
+}
+
+type entry struct {
+	key      string
+	size     int64
+	lastUsed time.Time
+}
+
+// evictTarget is the share of the limit eviction brings the cache down to,
+// leaving room so that the next items don't each trigger an eviction
+const evictTarget = 0.9
+
+func New(dir string, maxSizeMB int) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating cache folder %s: %w", dir, err)
+	}
+	log.WithFields(log.Fields{"dir": dir, "maxSizeMB": maxSizeMB}).Debug("Opening disk cache")
+	c := &Cache{
+		dir:      dir,
+		maxBytes: int64(maxSizeMB) * 1024 * 1024,
+		entries:  make(map[string]*entry),
+	}
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cache folder: %w", err)
+	}
+	for _, dirEntry := range dirEntries {
+		c.measure(dirEntry.Name())
+	}
+	return c, nil
+}
+
+// Path returns the location of the item stored under key
+func (c *Cache) Path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+// Touch marks the item as recently used
+func (c *Cache) Touch(key string) {
+	now := time.Now()
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		e.lastUsed = now
+	}
+	c.mu.Unlock()
+	// The modification time keeps the order of use across restarts
+	if err := os.Chtimes(c.Path(key), now, now); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Warnf("Error touching cache item %s", key)
+	}
+}
+
+// Remove deletes the item stored under key
+func (c *Cache) Remove(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forget(key)
+	return os.RemoveAll(c.Path(key))
+}
+
+// Add records the item just written under key and, when that takes the cache past its limit,
+// removes the least recently used items. Items listed in keep are never removed.
+func (c *Cache) Add(key string, keep ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.measure(key); err != nil {
+		return err
+	}
+	if c.total <= c.maxBytes {
+		return nil
+	}
+
+	entries := make([]*entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastUsed.Before(entries[j].lastUsed)
+	})
+	target := int64(float64(c.maxBytes) * evictTarget)
+	for _, e := range entries {
+		if c.total <= target {
+			break
+		}
+		if e.key == key || slices.Contains(keep, e.key) {
+			continue
+		}
+		log.Debugf("Evicting %s (%d bytes) from disk cache", e.key, e.size)
+		if err := os.RemoveAll(c.Path(e.key)); err != nil {
+			log.WithError(err).Errorf("Error evicting cache item %s", e.key)
+			continue
+		}
+		c.forget(e.key)
+	}
+	return nil
+}
+
+// measure reads the size and last use of the item under key from disk, replacing what was known of it
+func (c *Cache) measure(key string) error {
+	info, err := os.Stat(c.Path(key))
+	if err != nil {
+		c.forget(key)
+		return fmt.Errorf("error reading cache item %s: %w", key, err)
+	}
+	size := info.Size()
+	if info.IsDir() {
+		size = dirSize(c.Path(key))
+	}
+	c.forget(key)
+	c.entries[key] = &entry{key: key, size: size, lastUsed: info.ModTime()}
+	c.total += size
+	return nil
+}
+
+func (c *Cache) forget(key string) {
+	if e, ok := c.entries[key]; ok {
+		c.total -= e.size
+		delete(c.entries, key)
+	}
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
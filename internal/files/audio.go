@@ -0,0 +1,155 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"picshow/internal/kv"
+	"picshow/internal/utils"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func init() {
+	registerMediaHandler(func(h *handler) MediaHandler { return &audioHandler{h} })
+}
+
+// audioHandler indexes music and recordings with ffprobe, the thumbnail is the embedded
+// cover art or a waveform when there is none
+type audioHandler struct {
+	*handler
+}
+
+// audioProbe is what audioHandler reads about an audio file
+type audioProbe struct {
+	result   *probeResult
+	duration float64
+	hasCover bool
+}
+
+func (h *audioHandler) Type() utils.MimeType {
+	return utils.MimeTypeAudio
+}
+
+func (h *audioHandler) Detect(file *MediaFile) bool {
+	return strings.Contains(file.FullMimeType, "audio")
+}
+
+func (h *audioHandler) Probe(file *MediaFile) error {
+	log.Debugf("Processing new audio file: %s", file.Path)
+	probe, err := h.ffprobe(file.Path)
+	if err != nil {
+		return err
+	}
+
+	duration, err := strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil {
+		log.WithError(err).Errorf("Error parsing audio duration from %s", probe.Format.Duration)
+		return fmt.Errorf("error parsing audio duration: %w", err)
+	}
+
+	// The cover art sets the thumbnail dimensions, waveforms are twice as wide as high
+	if cover := probe.coverStream(); cover != nil && cover.Width > 0 && cover.Height > 0 {
+		file.Width = uint64(cover.Width)
+		file.Height = uint64(cover.Height)
+	} else {
+		file.Width = uint64(h.config.MaxThumbnailSize)
+		file.Height = uint64(h.config.MaxThumbnailSize / 2)
+	}
+	file.Probed = &audioProbe{result: probe, duration: duration, hasCover: probe.coverStream() != nil}
+	return nil
+}
+
+func (h *audioHandler) Thumbnail(file *MediaFile) error {
+	probe := file.Probed.(*audioProbe)
+	filePath := file.Path
+	thumbWidth, thumbHeight := h.thumbnailSize(file.Width, file.Height)
+
+	// Create temporary file for the thumbnail
+	thumbnailFile, err := os.CreateTemp("", "audio_thumbnail_*.jpg")
+	if err != nil {
+		log.WithError(err).Error("Error creating temporary file for audio thumbnail")
+		return fmt.Errorf("error creating temporary file for audio thumbnail: %w", err)
+	}
+	h.tempFiles.Store(thumbnailFile.Name(), thumbnailFile.Name())
+	defer os.Remove(thumbnailFile.Name())
+	defer h.tempFiles.Delete(thumbnailFile.Name())
+
+	args := []string{"-i", filePath}
+	if probe.hasCover {
+		log.Debugf("Extracting cover art of %s", filePath)
+		args = append(args,
+			"-an",
+			"-vframes", "1",
+			"-vf", fmt.Sprintf("scale=%d:%d:flags=fast_bilinear", thumbWidth, thumbHeight),
+		)
+	} else {
+		log.Debugf("Drawing waveform of %s", filePath)
+		args = append(args,
+			"-filter_complex", fmt.Sprintf("showwavespic=s=%dx%d:split_channels=0", thumbWidth, thumbHeight),
+			"-frames:v", "1",
+		)
+	}
+	args = append(args, "-f", "mjpeg", "-q:v", "5", "-y", thumbnailFile.Name())
+
+	ffmpegCmd := h.throttle.Command("ffmpeg", args...)
+	var ffmpegStderr bytes.Buffer
+	ffmpegCmd.Stderr = &ffmpegStderr
+	ffmpegCmdKey := fmt.Sprintf("ffmpeg_%s", thumbnailFile.Name())
+	h.processes.Store(ffmpegCmdKey, ffmpegCmd)
+	if err := ffmpegCmd.Run(); err != nil {
+		h.processes.Delete(ffmpegCmdKey)
+		log.WithError(err).Errorf("Error processing audio %s with FFmpeg", filePath)
+		return fmt.Errorf("error processing audio with FFmpeg: %w", newToolError(err, ffmpegStderr.String()))
+	}
+	h.processes.Delete(ffmpegCmdKey)
+	// Read the generated thumbnail file into memory
+	thumbnailData, err := os.ReadFile(thumbnailFile.Name())
+	if err != nil {
+		log.WithError(err).Errorf("Error reading thumbnail file %s", thumbnailFile.Name())
+		return fmt.Errorf("error reading thumbnail file: %w", err)
+	}
+
+	log.Debugf("Generated thumbnail for %s", filePath)
+	file.Thumbnail = thumbnailData
+	file.ThumbnailWidth = uint64(thumbWidth)
+	file.ThumbnailHeight = uint64(thumbHeight)
+	return nil
+}
+
+func (h *audioHandler) Metadata(file *MediaFile, record *kv.File) error {
+	probe := file.Probed.(*audioProbe)
+	audio := &kv.Audio{
+		FullMimeType:    file.FullMimeType,
+		Length:          uint64(probe.duration),
+		Bitrate:         probe.result.bitRate(),
+		Container:       probe.result.Format.FormatName,
+		Title:           probe.result.tag("title"),
+		Artist:          probe.result.tag("artist"),
+		Album:           probe.result.tag("album"),
+		Genre:           probe.result.tag("genre"),
+		Date:            probe.result.tag("date"),
+		ThumbnailWidth:  file.ThumbnailWidth,
+		ThumbnailHeight: file.ThumbnailHeight,
+		Blurhash:        file.BlurHash,
+		HasCoverArt:     probe.hasCover,
+	}
+	if audioStream := probe.result.audioStream(); audioStream != nil {
+		audio.Codec = audioStream.CodecName
+		audio.Channels = uint32(audioStream.Channels)
+		if sampleRate, err := strconv.ParseUint(audioStream.SampleRate, 10, 32); err == nil {
+			audio.SampleRate = uint32(sampleRate)
+		}
+		if audio.Bitrate == 0 {
+			audio.Bitrate, _ = strconv.ParseUint(audioStream.BitRate, 10, 64)
+		}
+	}
+	if creationTime := probe.result.creationTime(); creationTime != nil {
+		audio.CreationTime = timestamppb.New(*creationTime)
+	}
+	record.Media = &kv.File_Audio{Audio: audio}
+	return nil
+}
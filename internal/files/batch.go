@@ -0,0 +1,98 @@
+package files
+
+import (
+	"fmt"
+	"picshow/internal/kv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// batchFlushInterval is the longest a new file waits in a batch before it is written
+const batchFlushInterval = 5 * time.Second
+
+// fileBatch collects the files scans index and writes them with one transaction per batch.
+// Queued files keep their hash in inFlight until they are written so nothing indexes them twice.
+type fileBatch struct {
+	p    *Processor
+	size int
+
+	mu    sync.Mutex
+	files []*kv.File
+	paths []string
+	timer *time.Timer
+}
+
+func newFileBatch(p *Processor, size int) *fileBatch {
+	return &fileBatch{p: p, size: max(size, 1)}
+}
+
+// add queues a processed file, writing the batch once it is full
+func (b *fileBatch) add(filePath string, file *kv.File) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.files = append(b.files, file)
+	b.paths = append(b.paths, filePath)
+	if len(b.files) >= b.size {
+		b.flushLocked()
+		return
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(batchFlushInterval, func() { b.flush() })
+	}
+}
+
+// flush writes the queued files, the files that can't be written are recorded as failures
+func (b *fileBatch) flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flushLocked()
+}
+
+func (b *fileBatch) flushLocked() error {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.files) == 0 {
+		return nil
+	}
+	files, paths := b.files, b.paths
+	b.files, b.paths = nil, nil
+	defer func() {
+		for _, file := range files {
+			b.p.inFlight.Delete(file.Hash)
+		}
+	}()
+
+	if err := b.p.repo.AddBatch(files); err != nil {
+		// A single file or a concurrent write can fail the whole batch, the others are written one at a time
+		log.Warnf("Error writing batch of %d files, writing them one at a time: %v", len(files), err)
+		return b.addEach(files, paths)
+	}
+	log.Debugf("Wrote batch of %d new files", len(files))
+	b.p.status.new.Add(int64(len(files)))
+	return nil
+}
+
+// addEach writes files on their own, those that still fail are recorded as failures and their
+// thumbnails are removed
+func (b *fileBatch) addEach(files []*kv.File, paths []string) error {
+	var failed error
+	for i, file := range files {
+		if err := b.p.repo.AddFile(file); err != nil {
+			err = fmt.Errorf("error storing file %s: %w", file.Filename, err)
+			log.Error(err)
+			if err := b.p.repo.DeleteThumbnails(file.Hash); err != nil {
+				log.Errorf("Error removing thumbnails of %s: %v", file.Filename, err)
+			}
+			b.p.status.failed.Add(1)
+			b.p.recordFailure(paths[i], err)
+			failed = err
+			continue
+		}
+		b.p.status.new.Add(1)
+	}
+	return failed
+}
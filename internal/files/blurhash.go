@@ -0,0 +1,38 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"image/jpeg"
+
+	"github.com/bbrks/go-blurhash"
+	log "github.com/sirupsen/logrus"
+)
+
+// setBlurHash encodes the thumbnail of a file as the BlurHash listings paint while it loads.
+// Files without one get an empty placeholder, so failing to compute it isn't fatal.
+func setBlurHash(file *MediaFile) {
+	hash, err := encodeBlurHash(file.Thumbnail)
+	if err != nil {
+		log.WithError(err).Warnf("Error computing the placeholder of %s", file.Path)
+		return
+	}
+	file.BlurHash = hash
+}
+
+// encodeBlurHash takes more components along the longest side of the JPEG thumbnail
+func encodeBlurHash(thumbnail []byte) (string, error) {
+	img, err := jpeg.Decode(bytes.NewReader(thumbnail))
+	if err != nil {
+		return "", fmt.Errorf("error decoding thumbnail: %w", err)
+	}
+	xComponents, yComponents := 4, 3
+	if img.Bounds().Dy() > img.Bounds().Dx() {
+		xComponents, yComponents = 3, 4
+	}
+	hash, err := blurhash.Encode(xComponents, yComponents, img)
+	if err != nil {
+		return "", fmt.Errorf("error encoding blurhash: %w", err)
+	}
+	return hash, nil
+}
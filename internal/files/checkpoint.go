@@ -0,0 +1,165 @@
+package files
+
+import (
+	"path/filepath"
+	"picshow/internal/kv"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// checkpointInterval is how often a scan saves how far it got
+const checkpointInterval = 30 * time.Second
+
+// scanProgress moves the checkpoint of a scan forward as files are processed. Workers finish
+// files out of order so the cursor only passes a file once everything listed before it is done.
+type scanProgress struct {
+	repo *kv.Repository
+	// batch is written before each save so that the checkpoint never passes files that aren't stored
+	batch *fileBatch
+	// enabled is false when files aren't listed in a stable order, a cursor would mean nothing then
+	enabled    bool
+	checkpoint *kv.ScanCheckpoint
+	// resumedFrom is the cursor the scan started after, empty for a scan from the start
+	resumedFrom string
+
+	mu       sync.Mutex
+	pending  []string
+	done     map[string]bool
+	lastSave time.Time
+}
+
+// startProgress picks up the checkpoint left by an interrupted scan over the same roots
+func (p *Processor) startProgress(enabled bool) *scanProgress {
+	roots := p.config.ScanRoots()
+	progress := &scanProgress{
+		repo:    p.repo,
+		batch:   p.batch,
+		enabled: enabled,
+		checkpoint: &kv.ScanCheckpoint{
+			Roots:     roots,
+			StartedAt: timestamppb.Now(),
+		},
+		done:     make(map[string]bool),
+		lastSave: time.Now(),
+	}
+	if !enabled {
+		return progress
+	}
+	checkpoint, err := p.repo.GetCheckpoint()
+	if err != nil || checkpoint == nil {
+		return progress
+	}
+	if !slices.Equal(checkpoint.Roots, roots) {
+		log.Info("Discarding the checkpoint of a scan over other folders")
+		return progress
+	}
+	if checkpoint.Cursor != "" {
+		log.Infof("Resuming the scan started %s after %s", checkpoint.StartedAt.AsTime().Local().Format(time.DateTime), checkpoint.Cursor)
+		progress.checkpoint = checkpoint
+		progress.resumedFrom = checkpoint.Cursor
+	}
+	return progress
+}
+
+// resumed tells whether the scan skips the files an earlier scan already went through
+func (s *scanProgress) resumed() bool {
+	return s.resumedFrom != ""
+}
+
+// discovered queues a file in the order it was listed
+func (s *scanProgress) discovered(filePath string) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, filePath)
+}
+
+// finished marks a file as processed, whether or not it failed, and saves the checkpoint now and then
+func (s *scanProgress) finished(filePath string) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done[filePath] = true
+	s.checkpoint.Processed++
+	advanced := 0
+	for advanced < len(s.pending) && s.done[s.pending[advanced]] {
+		delete(s.done, s.pending[advanced])
+		s.checkpoint.Cursor = s.pending[advanced]
+		advanced++
+	}
+	s.pending = s.pending[advanced:]
+	if time.Since(s.lastSave) >= checkpointInterval {
+		s.saveLocked()
+	}
+}
+
+// save stores the checkpoint so that a restart resumes from it
+func (s *scanProgress) save() {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saveLocked()
+}
+
+func (s *scanProgress) saveLocked() {
+	s.batch.flush()
+	s.lastSave = time.Now()
+	s.checkpoint.SavedAt = timestamppb.New(s.lastSave)
+	if err := s.repo.SetCheckpoint(s.checkpoint); err != nil {
+		log.Errorf("Error saving scan checkpoint: %v", err)
+	}
+}
+
+// complete forgets the checkpoint once every file was walked
+func (s *scanProgress) complete() {
+	if err := s.repo.DeleteCheckpoint(); err != nil {
+		log.Errorf("Error removing scan checkpoint: %v", err)
+	}
+}
+
+// Where an entry of the walk stands against the cursor of a resumed scan
+const (
+	// walkAhead entries come after the cursor and are processed
+	walkAhead = iota
+	// walkDone entries were processed before the restart
+	walkDone
+	// walkContains folders hold the cursor, their files after it are processed
+	walkContains
+)
+
+// walkPosition compares the parts of two paths relative to the same root in the order the
+// walker lists them, by name folder by folder with a folder's files right after the folder
+func walkPosition(entry, cursor []string) int {
+	for i := range entry {
+		if i >= len(cursor) {
+			// Inside the cursor, listed right after it
+			return walkAhead
+		}
+		if entry[i] != cursor[i] {
+			if entry[i] < cursor[i] {
+				return walkDone
+			}
+			return walkAhead
+		}
+	}
+	if len(entry) < len(cursor) {
+		return walkContains
+	}
+	return walkDone
+}
+
+// splitPath cuts a relative path into its parts
+func splitPath(rel string) []string {
+	return strings.Split(rel, string(filepath.Separator))
+}
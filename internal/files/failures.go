@@ -0,0 +1,194 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"picshow/internal/kv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// The first retry waits retryBackoff, each following one twice as long
+	retryBackoff    = time.Hour
+	maxRetryBackoff = 7 * 24 * time.Hour
+)
+
+// toolError keeps what an external tool printed on stderr next to the error it failed with
+type toolError struct {
+	err    error
+	stderr string
+}
+
+func newToolError(err error, stderr string) error {
+	var exitErr *exec.ExitError
+	if stderr == "" && errors.As(err, &exitErr) {
+		// cmd.Output collects stderr in the error when nothing else reads it
+		stderr = string(exitErr.Stderr)
+	}
+	return &toolError{err: err, stderr: strings.TrimSpace(stderr)}
+}
+
+func (e *toolError) Error() string {
+	return e.err.Error()
+}
+
+func (e *toolError) Unwrap() error {
+	return e.err
+}
+
+// skipFailed tells whether a file that failed before is still waiting for its next retry.
+// A file that changed since it failed is retried right away.
+func (p *Processor) skipFailed(filename string, fileInfo os.FileInfo) bool {
+	failure, err := p.repo.GetFailure(filename)
+	if err != nil || failure == nil {
+		return false
+	}
+	if failure.Ignored {
+		log.Debugf("Skipping ignored file %s", filename)
+		return true
+	}
+	if !sameFile(failure, fileInfo) {
+		return false
+	}
+	if int(failure.Attempts) >= p.config.MaxFailedAttempts {
+		log.Debugf("Skipping %s after %d failed attempts", filename, failure.Attempts)
+		return true
+	}
+	if time.Now().Before(failure.NextRetry.AsTime()) {
+		log.Debugf("Skipping %s until %s", filename, failure.NextRetry.AsTime().Format(time.DateTime))
+		return true
+	}
+	return false
+}
+
+// recordFailure stores why a file couldn't be indexed and when to try it again
+func (p *Processor) recordFailure(filePath string, processErr error) {
+	filename := p.relativeName(filePath)
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		// Gone since, nothing to retry
+		return
+	}
+	failure, err := p.repo.GetFailure(filename)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	if failure == nil || !sameFile(failure, fileInfo) {
+		failure = &kv.Failure{
+			Filename:    filename,
+			FirstFailed: timestamppb.New(now),
+			Ignored:     failure.GetIgnored(),
+		}
+	}
+	failure.Attempts++
+	failure.Error = processErr.Error()
+	failure.Stderr = ""
+	var toolErr *toolError
+	if errors.As(processErr, &toolErr) {
+		failure.Stderr = toolErr.stderr
+	}
+	failure.LastFailed = timestamppb.New(now)
+	failure.NextRetry = timestamppb.New(now.Add(backoff(failure.Attempts)))
+	failure.Size = fileInfo.Size()
+	failure.LastModified = fileInfo.ModTime().Unix()
+	if err := p.repo.SetFailure(failure); err != nil {
+		log.Errorf("Error recording failure for %s: %v", filename, err)
+	}
+}
+
+func (p *Processor) forgetFailure(filePath string) {
+	filename := p.relativeName(filePath)
+	if err := p.repo.DeleteFailure(filename); err != nil {
+		log.Errorf("Error removing failure record for %s: %v", filename, err)
+	}
+}
+
+// removeStaleFailures forgets the failures of files that are gone
+func (p *Processor) removeStaleFailures() {
+	failures, err := p.repo.GetFailures()
+	if err != nil {
+		log.Errorf("Error fetching failures: %v", err)
+		return
+	}
+	for _, failure := range failures {
+		if _, err := os.Stat(filepath.Join(p.config.FolderPath, failure.Filename)); errors.Is(err, os.ErrNotExist) {
+			log.Debugf("Removing failure record of missing file %s", failure.Filename)
+			if err := p.repo.DeleteFailure(failure.Filename); err != nil {
+				log.Errorf("Error removing failure record for %s: %v", failure.Filename, err)
+			}
+		}
+	}
+}
+
+// sameFile tells whether the file is the one that failed, going by its size and modification time
+func sameFile(failure *kv.Failure, fileInfo os.FileInfo) bool {
+	return failure.Size == fileInfo.Size() && failure.LastModified == fileInfo.ModTime().Unix()
+}
+
+func backoff(attempts uint32) time.Duration {
+	wait := retryBackoff
+	for i := uint32(1); i < attempts && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxRetryBackoff)
+}
+
+// Retry processes a failed file right away, whatever its backoff or ignored state
+func (p *Processor) Retry(filename string) error {
+	if err := ResetFailure(p.repo, filename); err != nil {
+		return err
+	}
+
+	filePath := filepath.Join(p.config.FolderPath, filename)
+	existingFilesMap, existingFilesHashesMap, err := p.repo.FindAllFiles()
+	if err != nil {
+		return fmt.Errorf("error fetching existing files: %w", err)
+	}
+	if err := p.processFile(filePath, existingFilesMap, existingFilesHashesMap, &sync.Map{}); err != nil {
+		p.recordFailure(filePath, err)
+		return err
+	}
+	p.forgetFailure(filePath)
+	// A failed write is recorded by the batch
+	if err := p.batch.flush(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// IgnoreFailure marks a failure as ignored directly in the repository
+func IgnoreFailure(repo *kv.Repository, filename string) error {
+	failure, err := repo.GetFailure(filename)
+	if err != nil {
+		return err
+	}
+	if failure == nil {
+		return fmt.Errorf("no failure recorded for %s", filename)
+	}
+	failure.Ignored = true
+	return repo.SetFailure(failure)
+}
+
+// ResetFailure clears the backoff of a failure so that the next scan retries the file
+func ResetFailure(repo *kv.Repository, filename string) error {
+	failure, err := repo.GetFailure(filename)
+	if err != nil {
+		return err
+	}
+	if failure == nil {
+		return fmt.Errorf("no failure recorded for %s", filename)
+	}
+	failure.Ignored = false
+	failure.Attempts = 0
+	failure.NextRetry = timestamppb.Now()
+	return repo.SetFailure(failure)
+}
@@ -0,0 +1,184 @@
+package files
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"picshow/internal/config"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TempFilePrefix starts the names of the files picshow writes into the library while it works on them
+const TempFilePrefix = ".picshow-"
+
+// IgnoreFileName lists patterns of files to skip in the folder it is in, one glob per line.
+// Lines starting with # are comments and a leading ! includes files an earlier line excluded.
+const IgnoreFileName = ".picshowignore"
+
+// scanFilter decides which files get indexed, before they are hashed
+type scanFilter struct {
+	config     *config.Config
+	extensions map[string]bool
+
+	mu      sync.Mutex
+	ignores map[string][]ignoreRule
+}
+
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+func newScanFilter(config *config.Config) *scanFilter {
+	f := &scanFilter{
+		config:  config,
+		ignores: make(map[string][]ignoreRule),
+	}
+	if len(config.Extensions) > 0 {
+		f.extensions = make(map[string]bool, len(config.Extensions))
+		for _, ext := range config.Extensions {
+			f.extensions["."+strings.TrimPrefix(strings.ToLower(ext), ".")] = true
+		}
+	}
+	return f
+}
+
+// skip tells why a file must not be indexed, an empty reason means it is included
+func (f *scanFilter) skip(filePath string, info os.FileInfo) string {
+	name := filepath.Base(filePath)
+	if strings.HasPrefix(name, TempFilePrefix) || name == IgnoreFileName {
+		return "picshow file"
+	}
+	rel, err := filepath.Rel(f.config.FolderPath, filePath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = name
+	}
+	if !f.config.IncludeHidden {
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			if strings.HasPrefix(part, ".") {
+				return "hidden"
+			}
+		}
+	}
+	if f.extensions != nil && !f.extensions[strings.ToLower(filepath.Ext(name))] {
+		return "extension not allowed"
+	}
+	if info.Size() < f.config.MinFileSize {
+		return "smaller than MinFileSize"
+	}
+	if len(f.config.IncludePatterns) > 0 && !matchAny(f.config.IncludePatterns, name, rel) {
+		return "not matched by IncludePatterns"
+	}
+	if matchAny(f.config.ExcludePatterns, name, rel) {
+		return "matched by ExcludePatterns"
+	}
+	if f.ignored(filePath) {
+		return "matched by " + IgnoreFileName
+	}
+	return ""
+}
+
+func matchAny(patterns []string, name, rel string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored applies the ignore files of the folders between the library root and the file,
+// the closest one having the last word
+func (f *scanFilter) ignored(filePath string) bool {
+	root := filepath.Clean(f.config.FolderPath)
+	dirs := make([]string, 0)
+	for dir := filepath.Dir(filePath); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == root || dir == filepath.Dir(dir) || !strings.HasPrefix(dir, root) {
+			break
+		}
+	}
+
+	ignored := false
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(dirs[i], filePath)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		for _, rule := range f.rules(dirs[i]) {
+			if rule.matches(parts) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// matches checks the rule against the file name or, for folder rules, the folders it is in
+func (r ignoreRule) matches(parts []string) bool {
+	candidates := parts[len(parts)-1:]
+	if r.dirOnly {
+		candidates = parts[:len(parts)-1]
+	}
+	for _, candidate := range candidates {
+		if matched, _ := filepath.Match(r.pattern, candidate); matched {
+			return true
+		}
+	}
+	if !r.dirOnly && strings.Contains(r.pattern, "/") {
+		matched, _ := filepath.Match(r.pattern, filepath.ToSlash(filepath.Join(parts...)))
+		return matched
+	}
+	return false
+}
+
+func (f *scanFilter) rules(dir string) []ignoreRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if rules, found := f.ignores[dir]; found {
+		return rules
+	}
+	rules := readIgnoreFile(filepath.Join(dir, IgnoreFileName))
+	f.ignores[dir] = rules
+	return rules
+}
+
+func readIgnoreFile(ignorePath string) []ignoreRule {
+	file, err := os.Open(ignorePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Error reading %s: %v", ignorePath, err)
+		}
+		return nil
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		rule.pattern = strings.TrimPrefix(line, "/")
+		rules = append(rules, rule)
+	}
+	return rules
+}
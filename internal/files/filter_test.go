@@ -0,0 +1,57 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"picshow/internal/config"
+	"testing"
+)
+
+func TestScanFilter(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{
+		FolderPath:      root,
+		ExcludePatterns: []string{"*.tmp"},
+		Extensions:      []string{"JPG", ".mp4", "tmp"},
+		MinFileSize:     2,
+	}
+	if err := os.MkdirAll(filepath.Join(root, "trips", "drafts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ignore := "# generated previews\nprev_*\ndrafts/\n!prev_keep.jpg\n"
+	if err := os.WriteFile(filepath.Join(root, "trips", IgnoreFileName), []byte(ignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		skipped bool
+	}{
+		{"photo.jpg", "data", false},
+		{"clip.MP4", "data", false},
+		{"notes.txt", "data", true},
+		{"tiny.jpg", "d", true},
+		{"upload.tmp", "data", true},
+		{".hidden.jpg", "data", true},
+		{TempFilePrefix + "upload-1.jpg", "data", true},
+		{"trips/prev_1.jpg", "data", true},
+		{"trips/prev_keep.jpg", "data", false},
+		{"trips/drafts/photo.jpg", "data", true},
+		{"prev_1.jpg", "data", false},
+	}
+	filter := newScanFilter(cfg)
+	for _, tt := range tests {
+		filePath := filepath.Join(root, tt.name)
+		if err := os.WriteFile(filePath, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reason := filter.skip(filePath, info); (reason != "") != tt.skipped {
+			t.Errorf("skip(%s) = %q, want skipped %v", tt.name, reason, tt.skipped)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"picshow/internal/kv"
+	"picshow/internal/rendition"
+	"picshow/internal/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	registerMediaHandler(func(h *handler) MediaHandler { return &imageHandler{h} })
+}
+
+// imageHandler indexes photos with ImageMagick
+type imageHandler struct {
+	*handler
+}
+
+// imageProbe is what imageHandler reads about an image
+type imageProbe struct {
+	// source is what the thumbnail is made from, the display rendition of formats browsers can't show
+	source              string
+	orientation         utils.Orientation
+	hasDisplayRendition bool
+	animated            bool
+	// motionOffset is where the video embedded in a motion photo starts
+	motionOffset int64
+}
+
+func (h *imageHandler) Type() utils.MimeType {
+	return utils.MimeTypeImage
+}
+
+func (h *imageHandler) Detect(file *MediaFile) bool {
+	return strings.Contains(file.FullMimeType, "image") || rendition.IsRaw(file.Path)
+}
+
+func (h *imageHandler) Probe(file *MediaFile) error {
+	log.Debugf("Processing new image: %s", file.Path)
+	filePath := file.Path
+	probe := &imageProbe{}
+
+	// Browsers can't show HEIC, AVIF or RAW files so everything is derived from a JPEG rendition
+	probe.hasDisplayRendition = rendition.NeedsDisplay(filePath, file.FullMimeType)
+	if probe.hasDisplayRendition {
+		displayPath, err := h.display.EnsureWith(filePath, file.Hash, h.throttle.Prepare)
+		if err != nil {
+			log.WithError(err).Errorf("Error creating display rendition for %s", filePath)
+			return fmt.Errorf("error creating display rendition: %w", err)
+		}
+		filePath = displayPath
+	}
+
+	// Get the file extension to handle GIFs separately
+	ext := strings.ToLower(filepath.Ext(filePath))
+
+	if ext == ".gif" {
+		filePath = filePath + "[0]" // Identify the first frame of the GIF
+	}
+	probe.source = filePath
+	probe.animated = h.isAnimated(file.Path, file.FullMimeType)
+	if file.FullMimeType == "image/jpeg" {
+		probe.motionOffset = embeddedMotionOffset(file.Path)
+	}
+
+	cmdIdentify := h.throttle.Command("identify", "-format", "%wx%h %[orientation]", filePath)
+	identifyCmdKey := fmt.Sprintf("identify_%s", filePath)
+	h.processes.Store(identifyCmdKey, cmdIdentify)
+	output, err := cmdIdentify.Output()
+	if err != nil {
+		h.processes.Delete(identifyCmdKey)
+		log.WithError(err).Errorf("Error executing ImageMagick identify command on %s", filePath)
+		return fmt.Errorf("error executing ImageMagick identify command: %w", newToolError(err, ""))
+	}
+	h.processes.Delete(identifyCmdKey)
+	// Parse the output to get width, height and EXIF orientation
+	var width, height uint64
+	var orientationName string
+	_, err = fmt.Sscanf(string(output), "%dx%d %s", &width, &height, &orientationName)
+	if err != nil {
+		log.WithError(err).Errorf("Error parsing image dimensions from %s", string(output))
+		return fmt.Errorf("error parsing image dimensions: %w", err)
+	}
+	probe.orientation = parseOrientation(orientationName)
+	// Store the dimensions the image is displayed with, not the sensor ones
+	if probe.orientation.SwapsDimensions() {
+		width, height = height, width
+	}
+
+	file.Width = width
+	file.Height = height
+	file.Probed = probe
+	return nil
+}
+
+func (h *imageHandler) Thumbnail(file *MediaFile) error {
+	probe := file.Probed.(*imageProbe)
+	thumbWidth, thumbHeight := h.thumbnailSize(file.Width, file.Height)
+
+	// generate 5 random letters as fileName prefix
+	fileName := rand.New(rand.NewSource(time.Now().UnixNano())).Intn(100000)
+	// Generate temporary file path for thumbnail
+	tempFile := filepath.Join(os.TempDir(), strconv.FormatInt(int64(fileName), 10)+strconv.FormatUint(uint64(thumbWidth), 10)+"x"+strconv.FormatUint(uint64(thumbHeight), 10)+".jpg")
+	log.Debugf("Generating thumbnail for %s at %s", probe.source, tempFile)
+	// Construct and execute ImageMagick convert command
+	cmd := h.throttle.Command(
+		"convert",
+		probe.source,
+		"-auto-orient",
+		"-thumbnail", strconv.Itoa(int(thumbWidth))+"x"+strconv.Itoa(int(thumbHeight)),
+		"-depth", "8",
+		"-quality", "85",
+		"-filter", "Triangle",
+		tempFile,
+	)
+	var convertStderr bytes.Buffer
+	cmd.Stderr = &convertStderr
+	convCmdKey := fmt.Sprintf("conver_%s", tempFile)
+	h.processes.Store(convCmdKey, cmd)
+	err := cmd.Run()
+	if err != nil {
+		h.processes.Delete(convCmdKey)
+		log.WithError(err).Errorf("Error executing ImageMagick convert command on %s", probe.source)
+		return fmt.Errorf("error executing ImageMagick convert command: %w", newToolError(err, convertStderr.String()))
+	}
+	h.processes.Delete(convCmdKey)
+	h.tempFiles.Store(tempFile, tempFile)
+	defer os.Remove(tempFile)
+	defer h.tempFiles.Delete(tempFile)
+	// Read thumbnail file into memory
+	thumbnailData, err := os.ReadFile(tempFile)
+	if err != nil {
+		log.WithError(err).Errorf("Error reading thumbnail file %s", tempFile)
+		return fmt.Errorf("error reading thumbnail file: %w", err)
+	}
+
+	log.Debugf("Generated thumbnail for %s", probe.source)
+	file.Thumbnail = thumbnailData
+	file.ThumbnailWidth = uint64(thumbWidth)
+	file.ThumbnailHeight = uint64(thumbHeight)
+	return nil
+}
+
+func (h *imageHandler) Metadata(file *MediaFile, record *kv.File) error {
+	probe := file.Probed.(*imageProbe)
+	record.Media = &kv.File_Image{Image: &kv.Image{
+		FullMimeType:        file.FullMimeType,
+		Width:               file.Width,
+		Height:              file.Height,
+		ThumbnailWidth:      file.ThumbnailWidth,
+		ThumbnailHeight:     file.ThumbnailHeight,
+		Blurhash:            file.BlurHash,
+		Orientation:         uint32(probe.orientation),
+		OriginalFormat:      rendition.OriginalFormat(file.Path, file.FullMimeType),
+		HasDisplayRendition: probe.hasDisplayRendition,
+		Animated:            probe.animated,
+		Motion:              probe.motionOffset > 0,
+		MotionOffset:        probe.motionOffset,
+	}}
+	return nil
+}
+
+// Formats that can hold more than one frame
+var animatedMimeTypes = []string{"image/gif", "image/webp", "image/png", "image/apng"}
+
+// isAnimated tells whether the image has more than one frame and gets a preview
+func (h *imageHandler) isAnimated(filePath, fullMimeType string) bool {
+	if !slices.Contains(animatedMimeTypes, fullMimeType) {
+		return false
+	}
+	// ImageMagick counts the frames of an APNG as one, the animation chunk tells instead
+	if fullMimeType == "image/png" || fullMimeType == "image/apng" {
+		return isAnimatedPNG(filePath)
+	}
+	cmd := h.throttle.Command("identify", "-format", "%n\n", filePath)
+	identifyCmdKey := fmt.Sprintf("identify_frames_%s", filePath)
+	h.processes.Store(identifyCmdKey, cmd)
+	defer h.processes.Delete(identifyCmdKey)
+	output, err := cmd.Output()
+	if err != nil {
+		log.WithError(err).Warnf("Error counting the frames of %s", filePath)
+		return false
+	}
+	// identify prints the count once per frame
+	first, _, _ := strings.Cut(string(output), "\n")
+	frames, err := strconv.Atoi(strings.TrimSpace(first))
+	return err == nil && frames > 1
+}
+
+// isAnimatedPNG looks for the acTL chunk that APNG files have before their image data
+func isAnimatedPNG(filePath string) bool {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	signature := make([]byte, 8)
+	if _, err := io.ReadFull(reader, signature); err != nil || string(signature) != "\x89PNG\r\n\x1a\n" {
+		return false
+	}
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return false
+		}
+		switch string(header[4:]) {
+		case "acTL":
+			return true
+		case "IDAT", "IEND":
+			return false
+		}
+		// Skip the chunk data and its CRC
+		if _, err := reader.Discard(int(binary.BigEndian.Uint32(header[:4])) + 4); err != nil {
+			return false
+		}
+	}
+}
+
+// ImageMagick names of the EXIF orientations, in EXIF order
+var orientationNames = []string{"TopLeft", "TopRight", "BottomRight", "BottomLeft", "LeftTop", "RightTop", "RightBottom", "LeftBottom"}
+
+func parseOrientation(name string) utils.Orientation {
+	if i := slices.Index(orientationNames, name); i >= 0 {
+		return utils.Orientation(i + 1)
+	}
+	return 0
+}
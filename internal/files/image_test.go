@@ -0,0 +1,83 @@
+package files
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsAnimatedPNG(t *testing.T) {
+	chunk := func(kind string, data []byte) []byte {
+		length := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+		return append(append(append(length, kind...), data...), 0, 0, 0, 0)
+	}
+	png := func(chunks ...[]byte) []byte {
+		data := []byte("\x89PNG\r\n\x1a\n")
+		for _, c := range chunks {
+			data = append(data, c...)
+		}
+		return data
+	}
+	header := chunk("IHDR", make([]byte, 13))
+
+	tests := []struct {
+		name     string
+		data     []byte
+		animated bool
+	}{
+		{"still.png", png(header, chunk("IDAT", []byte{1}), chunk("IEND", nil)), false},
+		{"animated.png", png(header, chunk("acTL", make([]byte, 8)), chunk("IDAT", []byte{1})), true},
+		{"late.png", png(header, chunk("IDAT", []byte{1}), chunk("acTL", make([]byte, 8))), false},
+		{"truncated.png", png(header[:6]), false},
+		{"other.png", []byte("GIF89a"), false},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, tt.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := isAnimatedPNG(path); got != tt.animated {
+			t.Errorf("isAnimatedPNG(%s) = %v, want %v", tt.name, got, tt.animated)
+		}
+	}
+}
+
+func TestEncodeBlurHash(t *testing.T) {
+	thumbnail := func(width, height int) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				img.Set(x, y, color.RGBA{uint8(x * 4), 100, uint8(y * 4), 255})
+			}
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, nil); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	// The first character encodes the components, 4x3 is 'L' and 3x4 is 'T'
+	for _, tc := range []struct {
+		width, height int
+		sizeFlag      byte
+	}{{64, 48, 'L'}, {48, 64, 'T'}} {
+		hash, err := encodeBlurHash(thumbnail(tc.width, tc.height))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hash) != 28 || hash[0] != tc.sizeFlag {
+			t.Errorf("blurhash of %dx%d = %q, want 28 characters starting with %c", tc.width, tc.height, hash, tc.sizeFlag)
+		}
+	}
+
+	if _, err := encodeBlurHash([]byte("not a jpeg")); err == nil {
+		t.Error("encoding a broken thumbnail succeeded")
+	}
+}
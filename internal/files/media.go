@@ -0,0 +1,98 @@
+package files
+
+import (
+	"fmt"
+	"picshow/internal/kv"
+	"picshow/internal/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MediaFile is a new file on its way through a MediaHandler, each step fills in its part
+type MediaFile struct {
+	// Path is where the file is, Hash the key it is indexed under
+	Path string
+	Hash string
+	// FullMimeType is the type `file` reports, like image/jpeg
+	FullMimeType string
+
+	// Width and Height are the dimensions the file is displayed with, set by Probe
+	Width  uint64
+	Height uint64
+	// Probed holds what Probe read for the handler's own use in the later steps
+	Probed any
+
+	// Thumbnail is a JPEG within MaxThumbnailSize, set by Thumbnail. It is stored apart from the
+	// record as the large JPEG thumbnail.
+	Thumbnail       []byte
+	ThumbnailWidth  uint64
+	ThumbnailHeight uint64
+	// BlurHash is the placeholder of the thumbnail, set after Thumbnail
+	BlurHash string
+}
+
+// MediaHandler indexes one kind of media. Scans and uploads pick the first registered handler
+// that detects a file, then probe it, make its thumbnail and store its metadata in that order.
+type MediaHandler interface {
+	// Type is what files of this kind are stored and filtered as
+	Type() utils.MimeType
+	// Detect tells whether the handler takes the file, only Path and FullMimeType are set
+	Detect(file *MediaFile) bool
+	// Probe reads the dimensions and properties of the file
+	Probe(file *MediaFile) error
+	// Thumbnail renders the thumbnail of the file
+	Thumbnail(file *MediaFile) error
+	// Metadata stores what was read about the file into its record
+	Metadata(file *MediaFile, record *kv.File) error
+}
+
+// mediaHandlers builds the handlers a processor registers, in the order they detect files
+var mediaHandlers []func(h *handler) MediaHandler
+
+// registerMediaHandler adds a kind of media to every processor, handlers register from init
+func registerMediaHandler(newMediaHandler func(h *handler) MediaHandler) {
+	mediaHandlers = append(mediaHandlers, newMediaHandler)
+}
+
+// RegisterMediaHandler adds a kind of media to this processor, it is tried after the built-in ones
+func (p *Processor) RegisterMediaHandler(media MediaHandler) {
+	p.media = append(p.media, media)
+}
+
+// detectMedia finds the handler of a file, nil when no handler takes it
+func (p *Processor) detectMedia(filePath string) (MediaHandler, *MediaFile) {
+	file := &MediaFile{Path: filePath, FullMimeType: getFullMimeType(filePath)}
+	for _, media := range p.media {
+		if media.Detect(file) {
+			return media, file
+		}
+	}
+	return nil, nil
+}
+
+// prepareNewFile runs a new file through its handler, storing the record is up to the caller
+func (p *Processor) prepareNewFile(media MediaHandler, file *MediaFile, record *kv.File) error {
+	file.Hash = record.Hash
+	record.MimeType = media.Type().String()
+	if err := media.Probe(file); err != nil {
+		return fmt.Errorf("error probing %s %s: %w", media.Type(), file.Path, err)
+	}
+	if err := media.Thumbnail(file); err != nil {
+		return fmt.Errorf("error creating thumbnail of %s %s: %w", media.Type(), file.Path, err)
+	}
+	setBlurHash(file)
+	// Without the other sizes the thumbnail the handler made is served in their place
+	blobs, thumbnails, err := p.handler.thumbnailVariants(file)
+	if err != nil {
+		log.WithError(err).Warnf("Error creating thumbnail sizes of %s", file.Path)
+		blobs, thumbnails = largeThumbnail(file)
+	}
+	if err := media.Metadata(file, record); err != nil {
+		return fmt.Errorf("error reading metadata of %s %s: %w", media.Type(), file.Path, err)
+	}
+	if err := p.repo.SetThumbnails(record.Hash, blobs); err != nil {
+		return fmt.Errorf("error storing thumbnails of %s %s: %w", media.Type(), file.Path, err)
+	}
+	record.Thumbnails = thumbnails
+	return nil
+}
@@ -0,0 +1,170 @@
+package files
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"picshow/internal/kv"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Live Photos are a still and a short video sharing a name, like IMG_1234.HEIC and IMG_1234.MOV
+var (
+	stillExtensions  = []string{".heic", ".heif", ".jpg", ".jpeg"}
+	motionExtensions = []string{".mov", ".mp4"}
+)
+
+// maxMotionLength is the longest video, in seconds, taken for the motion of a still. Live Photos last about three.
+const maxMotionLength = 4
+
+// counterparts lists the names a file's Live Photo counterpart could be indexed under
+func counterparts(name string, extensions []string) []string {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	names := make([]string, 0, 2*len(extensions))
+	for _, ext := range extensions {
+		names = append(names, base+strings.ToUpper(ext), base+ext)
+	}
+	return names
+}
+
+// pairMotionPhotos links the videos of the library to the stills they are the motion of
+func (p *Processor) pairMotionPhotos() {
+	names, _, err := p.repo.FindAllFiles()
+	if err != nil {
+		log.Errorf("Error fetching existing files from repository: %v", err)
+		return
+	}
+	fileIds, err := p.repo.GetAllFileIds()
+	if err != nil {
+		log.Errorf("Error fetching file lists: %v", err)
+		return
+	}
+	linked := make(map[uint64]bool, len(fileIds.MotionFileIds))
+	for _, id := range fileIds.MotionFileIds {
+		linked[id] = true
+	}
+	names.Range(func(key, value interface{}) bool {
+		name, _ := key.(string)
+		videoID, _ := value.(uint64)
+		if linked[videoID] || !slices.Contains(motionExtensions, strings.ToLower(filepath.Ext(name))) {
+			return true
+		}
+		for _, stillName := range counterparts(name, stillExtensions) {
+			if stillID, found := names.Load(stillName); found {
+				p.linkMotion(stillID.(uint64), videoID)
+				break
+			}
+		}
+		return true
+	})
+}
+
+// pairMotion links a new file to its Live Photo counterpart when that one is indexed already
+func (p *Processor) pairMotion(file *kv.File) {
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	switch {
+	case file.GetVideo() != nil && slices.Contains(motionExtensions, ext):
+		if stillID, found := p.lookupCounterpart(file.Filename, stillExtensions); found {
+			p.linkMotion(stillID, file.Id)
+		}
+	case file.GetImage() != nil && slices.Contains(stillExtensions, ext):
+		if videoID, found := p.lookupCounterpart(file.Filename, motionExtensions); found {
+			p.linkMotion(file.Id, videoID)
+		}
+	}
+}
+
+func (p *Processor) lookupCounterpart(name string, extensions []string) (uint64, bool) {
+	for _, candidate := range counterparts(name, extensions) {
+		id, found, err := p.repo.LookupFileName(candidate)
+		if err != nil {
+			log.Errorf("Error looking up %s: %v", candidate, err)
+			return 0, false
+		}
+		if found {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// linkMotion links a still and a video that share a name when the video is short enough to be a Live Photo
+func (p *Processor) linkMotion(stillID, videoID uint64) {
+	still, err := p.repo.GetFileByID(stillID)
+	if err != nil {
+		log.Errorf("Error fetching file %d: %v", stillID, err)
+		return
+	}
+	video, err := p.repo.GetFileByID(videoID)
+	if err != nil {
+		log.Errorf("Error fetching file %d: %v", videoID, err)
+		return
+	}
+	if still.GetImage() == nil || still.GetImage().PairedVideo() != nil ||
+		video.GetVideo() == nil || video.MotionOf != nil || video.GetVideo().Length > maxMotionLength {
+		return
+	}
+	if err := p.repo.LinkMotion(stillID, videoID); err != nil {
+		log.Errorf("Error pairing %s with %s: %v", video.Filename, still.Filename, err)
+		return
+	}
+	log.Infof("Paired %s as the motion of %s", video.Filename, still.Filename)
+}
+
+// Motion photos carry an MP4 after the JPEG data, their XMP metadata tells how long it is
+var (
+	microVideoOffset = regexp.MustCompile(`MicroVideoOffset="(\d+)"`)
+	containerItem    = regexp.MustCompile(`<Container:Item[^>]*>`)
+	itemLength       = regexp.MustCompile(`Item:Length="(\d+)"`)
+)
+
+// xmpSearchSize is how much of the start of a JPEG is searched for the XMP metadata
+const xmpSearchSize = 256 * 1024
+
+// embeddedMotionOffset finds where the video of a Google motion photo starts, 0 when there is none
+func embeddedMotionOffset(filePath string) int64 {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+	head := make([]byte, min(info.Size(), xmpSearchSize))
+	if _, err := io.ReadFull(f, head); err != nil {
+		return 0
+	}
+
+	var length int64
+	if match := microVideoOffset.FindSubmatch(head); match != nil {
+		length, _ = strconv.ParseInt(string(match[1]), 10, 64)
+	} else {
+		for _, item := range containerItem.FindAll(head, -1) {
+			if !strings.Contains(string(item), `Item:Semantic="MotionPhoto"`) {
+				continue
+			}
+			if match := itemLength.FindSubmatch(item); match != nil {
+				length, _ = strconv.ParseInt(string(match[1]), 10, 64)
+			}
+		}
+	}
+	if length <= 0 || length >= info.Size() {
+		return 0
+	}
+
+	// The video is an MP4 so it opens with an ftyp box
+	offset := info.Size() - length
+	box := make([]byte, 8)
+	if _, err := f.ReadAt(box, offset); err != nil || string(box[4:]) != "ftyp" {
+		log.Debugf("Motion photo metadata of %s doesn't point to a video", filePath)
+		return 0
+	}
+	return offset
+}
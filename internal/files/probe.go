@@ -0,0 +1,206 @@
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ffprobe reads the format and streams of a media file
+func (h *handler) ffprobe(filePath string) (*probeResult, error) {
+	// Run ffprobe as an external command
+	cmd := h.throttle.Command("ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		filePath)
+	ffprobeCmdKey := fmt.Sprintf("ffprobe_%s", filePath)
+	h.processes.Store(ffprobeCmdKey, cmd)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		h.processes.Delete(ffprobeCmdKey)
+		log.WithError(err).Errorf("Error running ffprobe on %s\nstderr: %s", filePath, stderr.String())
+		return nil, fmt.Errorf("error running ffprobe: %w", newToolError(err, stderr.String()))
+	}
+	h.processes.Delete(ffprobeCmdKey)
+
+	// Parse the JSON output
+	var probe probeResult
+	if err := json.Unmarshal(stdout.Bytes(), &probe); err != nil {
+		log.WithError(err).Errorf("Error parsing ffprobe output for %s", filePath)
+		return nil, fmt.Errorf("error parsing ffprobe output: %w", err)
+	}
+	return &probe, nil
+}
+
+// probeResult is the subset of `ffprobe -show_format -show_streams` output that we keep
+type probeResult struct {
+	Streams []probeStream `json:"streams"`
+	Format  probeFormat   `json:"format"`
+}
+
+type probeStream struct {
+	CodecType    string            `json:"codec_type"`
+	CodecName    string            `json:"codec_name"`
+	Width        int               `json:"width"`
+	Height       int               `json:"height"`
+	BitRate      string            `json:"bit_rate"`
+	AvgFrameRate string            `json:"avg_frame_rate"`
+	RFrameRate   string            `json:"r_frame_rate"`
+	Channels     int               `json:"channels"`
+	SampleRate   string            `json:"sample_rate"`
+	Disposition  probeDisposition  `json:"disposition"`
+	Tags         map[string]string `json:"tags"`
+	SideDataList []probeSideData   `json:"side_data_list"`
+}
+
+type probeDisposition struct {
+	AttachedPic int `json:"attached_pic"`
+}
+
+type probeSideData struct {
+	SideDataType  string  `json:"side_data_type"`
+	DisplayMatrix string  `json:"displaymatrix"`
+	Rotation      float64 `json:"rotation"`
+}
+
+type probeFormat struct {
+	FormatName string            `json:"format_name"`
+	Duration   string            `json:"duration"`
+	BitRate    string            `json:"bit_rate"`
+	Tags       map[string]string `json:"tags"`
+}
+
+// videoStream returns the first video stream, if any, skipping embedded cover art
+func (r *probeResult) videoStream() *probeStream {
+	for i := range r.Streams {
+		if r.Streams[i].CodecType == "video" && r.Streams[i].Disposition.AttachedPic == 0 {
+			return &r.Streams[i]
+		}
+	}
+	return nil
+}
+
+// coverStream returns the cover art embedded in an audio file, if any
+func (r *probeResult) coverStream() *probeStream {
+	for i := range r.Streams {
+		if r.Streams[i].CodecType == "video" && r.Streams[i].Disposition.AttachedPic == 1 {
+			return &r.Streams[i]
+		}
+	}
+	return nil
+}
+
+// audioStream returns the first audio stream, if any
+func (r *probeResult) audioStream() *probeStream {
+	return r.firstStream("audio")
+}
+
+func (r *probeResult) firstStream(codecType string) *probeStream {
+	for i := range r.Streams {
+		if r.Streams[i].CodecType == codecType {
+			return &r.Streams[i]
+		}
+	}
+	return nil
+}
+
+// bitRate prefers the container bitrate since many muxers leave the stream one empty
+func (r *probeResult) bitRate() uint64 {
+	if bitRate, err := strconv.ParseUint(r.Format.BitRate, 10, 64); err == nil {
+		return bitRate
+	}
+	if stream := r.videoStream(); stream != nil {
+		if bitRate, err := strconv.ParseUint(stream.BitRate, 10, 64); err == nil {
+			return bitRate
+		}
+	}
+	return 0
+}
+
+// creationTime reads the creation_time tag from the container, then from the video stream
+func (r *probeResult) creationTime() *time.Time {
+	value := r.Format.Tags["creation_time"]
+	if stream := r.videoStream(); value == "" && stream != nil {
+		value = stream.Tags["creation_time"]
+	}
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// tag reads a container tag, falling back to the first audio stream. Tag names are
+// matched case insensitively since every tagging format spells them differently.
+func (r *probeResult) tag(name string) string {
+	sources := []map[string]string{r.Format.Tags}
+	if stream := r.audioStream(); stream != nil {
+		sources = append(sources, stream.Tags)
+	}
+	for _, tags := range sources {
+		for key, value := range tags {
+			if strings.EqualFold(key, name) && strings.TrimSpace(value) != "" {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+	return ""
+}
+
+// frameRate parses the "num/den" rational ffprobe uses for frame rates
+func (s *probeStream) frameRate() float64 {
+	for _, rate := range []string{s.AvgFrameRate, s.RFrameRate} {
+		num, den, found := strings.Cut(rate, "/")
+		if !found {
+			continue
+		}
+		n, errNum := strconv.ParseFloat(num, 64)
+		d, errDen := strconv.ParseFloat(den, 64)
+		if errNum != nil || errDen != nil || d == 0 || n == 0 {
+			continue
+		}
+		return math.Round(n/d*100) / 100
+	}
+	return 0
+}
+
+// rotation returns the clockwise rotation to apply for display, normalized to 0, 90, 180 or 270.
+// Newer ffprobe versions report it in the display matrix side data, older ones in the rotate tag.
+func (s *probeStream) rotation() int32 {
+	degrees := 0.0
+	if sideData := s.displayMatrix(); sideData != nil {
+		// The display matrix rotation is counter-clockwise
+		degrees = -sideData.Rotation
+	} else if rotate, err := strconv.ParseFloat(s.Tags["rotate"], 64); err == nil {
+		degrees = rotate
+	}
+	normalized := int32(math.Round(degrees/90)) * 90 % 360
+	if normalized < 0 {
+		normalized += 360
+	}
+	return normalized
+}
+
+func (s *probeStream) displayMatrix() *probeSideData {
+	for i := range s.SideDataList {
+		if s.SideDataList[i].SideDataType == "Display Matrix" {
+			return &s.SideDataList[i]
+		}
+	}
+	return nil
+}
@@ -1,16 +1,20 @@
 package files
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"picshow/internal/config"
 	"picshow/internal/kv"
+	"picshow/internal/rendition"
+	"picshow/internal/throttle"
 	"picshow/internal/utils"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -26,27 +30,106 @@ type Processor struct {
 	tempFiles   *sync.Map
 	batchSize   int
 	concurrency int
+	// hashFile computes the key files are identified by across renames
+	hashFile func(filePath string) (string, error)
+	// inFlight holds the hashes of new files being processed so that a scan
+	// and an upload of the same content don't both index it
+	inFlight *sync.Map
+	// running is held for the duration of a scan so that two never overlap
+	running  sync.Mutex
+	status   scanStatus
+	requests chan struct{}
+	// throttle slows scans down, uploads and retries aren't held back by it
+	throttle *throttle.Throttle
+	// batch writes the new files scans find, BatchSize at a time
+	batch *fileBatch
+	// media are the handlers of the kinds of files that get indexed, in the order they detect files
+	media []MediaHandler
+	// regenerating is held while thumbnails are regenerated so that two regenerations never overlap
+	regenerating sync.Mutex
+	regeneration regenerationStatus
+	// deferredDuplicates are the copies found while their indexed file was still being written
+	deferredDuplicates   []deferredDuplicate
+	deferredDuplicatesMu sync.Mutex
 }
 
 func NewProcessor(
 	config *config.Config,
 	repo *kv.Repository,
+	display *rendition.Display,
 	batchSize, concurrency int,
 ) *Processor {
 	log.Debug("Creating new Processor instance")
-	return &Processor{
+	scanThrottle := throttle.New(config)
+	processes, tempFiles := &sync.Map{}, &sync.Map{}
+	handler := newHandler(config, display, scanThrottle, processes, tempFiles)
+	p := &Processor{
 		repo:        repo,
 		config:      config,
-		handler:     newHandler(config),
+		handler:     handler,
+		hashFile:    handler.generateFileKey,
 		batchSize:   batchSize,
 		concurrency: concurrency,
-		processes:   &sync.Map{},
-		tempFiles:   &sync.Map{},
+		processes:   processes,
+		tempFiles:   tempFiles,
+		inFlight:    &sync.Map{},
+		requests:    make(chan struct{}, 1),
+		throttle:    scanThrottle,
 	}
+	p.batch = newFileBatch(p, batchSize)
+	for _, newMediaHandler := range mediaHandlers {
+		p.media = append(p.media, newMediaHandler(handler))
+	}
+	return p
+}
+
+// Throttle is what the server reports its requests to so that scans make way for them
+func (p *Processor) Throttle() *throttle.Throttle {
+	return p.throttle
+}
+
+// Status returns the progress of the running scan, or the outcome of the last one
+func (p *Processor) Status() ScanStatus {
+	status := p.status.snapshot()
+	if status.Phase == PhaseScanning {
+		status.Paused = p.throttle.Paused()
+	}
+	return status
+}
+
+// RequestScan asks for a scan to start now, the request is picked up from ScanRequests
+func (p *Processor) RequestScan() error {
+	if !p.running.TryLock() {
+		return ErrScanRunning
+	}
+	p.running.Unlock()
+	if !p.throttle.InWindow(time.Now()) {
+		return ErrOutsideScanWindow
+	}
+	select {
+	case p.requests <- struct{}{}:
+	default:
+		// A request is already pending
+	}
+	return nil
 }
 
-func (p *Processor) Process(ctx context.Context) error {
+// ScanRequests delivers the scans requested through RequestScan
+func (p *Processor) ScanRequests() <-chan struct{} {
+	return p.requests
+}
+
+func (p *Processor) Process(ctx context.Context) (err error) {
+	if !p.running.TryLock() {
+		return ErrScanRunning
+	}
+	defer p.running.Unlock()
+	if !p.throttle.InWindow(time.Now()) {
+		return ErrOutsideScanWindow
+	}
 	log.Info("Starting processing files")
+	p.status.start()
+	defer func() { p.status.finish(err) }()
 
 	// Create a new context that we can cancel
 	processCtx, cancelProcess := context.WithCancel(ctx)
@@ -71,9 +154,12 @@ func (p *Processor) Process(ctx context.Context) error {
 	}
 	log.Debug("Fetched existing files from repository")
 
+	fdCommand := p.fdCommand()
+	// fd lists files in no particular order so only scans with the built-in walker can resume
+	progress := p.startProgress(fdCommand == "")
+
 	processedHashes := &sync.Map{}
 	fileChan := make(chan string, p.concurrency)
-	errChan := make(chan error, p.concurrency)
 	var wg sync.WaitGroup
 	var processedFiles int64
 
@@ -103,87 +189,233 @@ func (p *Processor) Process(ctx context.Context) error {
 		go func() {
 			defer wg.Done()
 			for filePath := range fileChan {
+				if err := p.throttle.Wait(processCtx); err != nil {
+					return
+				}
 				if err := p.processFile(filePath, existingFilesMap, existingFilesHashesMap, processedHashes); err != nil {
-					errChan <- fmt.Errorf("error processing file %s: %w", filePath, err)
+					log.Errorf("error processing file %s: %v", filePath, err)
+					p.status.failed.Add(1)
+					p.recordFailure(filePath, err)
+				} else {
+					p.forgetFailure(filePath)
 				}
+				progress.finished(filePath)
 				atomic.AddInt64(&processedFiles, 1)
+				p.status.processed.Add(1)
 			}
 		}()
 	}
 
-	fdCommand, err := findFdCommand()
-	if err != nil {
-		return fmt.Errorf("error finding fd command: %w", err)
-	}
-	log.Infof("Using %s command for file discovery", fdCommand)
-
-	// Use fd to stream files
-	cmd := exec.CommandContext(processCtx, fdCommand, ".", "-t", "f", "-d", "1", p.config.FolderPath)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("error creating stdout pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("error starting %s command: %w", fdCommand, err)
-	}
-
-	scanner := bufio.NewScanner(stdout)
-	for scanner.Scan() {
+	filter := newScanFilter(p.config)
+	complete, err := p.discover(processCtx, fdCommand, progress.resumedFrom, func(filePath string) error {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			log.Debugf("Skipping %s: %v", filePath, err)
+			// Only a file that is gone loses its record, one that couldn't be read keeps it
+			if !errors.Is(err, fs.ErrNotExist) {
+				existingFilesMap.Delete(p.relativeName(filePath))
+			}
+			return nil
+		}
+		// Skipped files are left out of the index, whether or not they were in it
+		if reason := filter.skip(filePath, info); reason != "" {
+			log.Debugf("Skipping %s: %s", filePath, reason)
+			return nil
+		}
+		progress.discovered(filePath)
 		select {
 		case <-processCtx.Done():
 			return processCtx.Err()
-		case fileChan <- scanner.Text():
+		case fileChan <- filePath:
+			p.status.discovered.Add(1)
+			return nil
 		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Errorf("Error reading %s output: %v", fdCommand, err)
-	}
-
-	if err := cmd.Wait(); err != nil {
-		log.Errorf("%s command finished with error: %v", fdCommand, err)
-	}
+	})
+	p.status.finishDiscovery()
 
 	close(fileChan)
 	wg.Wait()
-	close(errChan)
-
-	// Check for any errors during processing
-	for err := range errChan {
-		log.Error(err)
+	p.batch.flush()
+	p.handleDeferredDuplicates()
+	if err == nil {
+		// Workers stop on cancellation, possibly after the walk ended
+		err = ctx.Err()
+	}
+	if err != nil {
+		progress.save()
+		return err
 	}
 
-	p.removeNonExistentFiles(existingFilesMap)
+	p.status.setPhase(PhaseCleanup)
+	if progress.resumed() {
+		// The files listed before the restart weren't seen again, they would look deleted
+		log.Info("Keeping records of missing files until the next complete scan")
+	} else if !complete {
+		log.Warn("Some folders couldn't be read, keeping records of missing files until the next complete scan")
+	} else {
+		p.removeNonExistentFiles(existingFilesMap)
+	}
+	p.removeStaleDuplicates()
+	p.removeStaleFailures()
+	p.pairMotionPhotos()
 	p.repo.UpdateFavoriteCount()
+	progress.complete()
 	log.Info("Completed processing files")
 	return nil
 }
 
-func findFdCommand() (string, error) {
-	possibleCommands := []string{"fd", "fdfind", "fd-find"}
+// deferredDuplicate is a copy of a file that wasn't written yet when the copy was found
+type deferredDuplicate struct {
+	filePath, filename, hash string
+}
+
+// deferDuplicate handles a copy once the batch its indexed file waits in is written
+func (p *Processor) deferDuplicate(filePath, filename, hash string) {
+	log.Debugf("Handling duplicate %s once the file it copies is stored", filename)
+	p.deferredDuplicatesMu.Lock()
+	defer p.deferredDuplicatesMu.Unlock()
+	p.deferredDuplicates = append(p.deferredDuplicates, deferredDuplicate{filePath, filename, hash})
+}
 
-	for _, cmd := range possibleCommands {
-		if _, err := exec.LookPath(cmd); err == nil {
-			return cmd, nil
+// handleDeferredDuplicates applies the duplicate policy to the copies found while their indexed
+// file was in the batch, it must run after the batch is flushed
+func (p *Processor) handleDeferredDuplicates() {
+	p.deferredDuplicatesMu.Lock()
+	duplicates := p.deferredDuplicates
+	p.deferredDuplicates = nil
+	p.deferredDuplicatesMu.Unlock()
+	for _, duplicate := range duplicates {
+		if !p.handleDuplicateFile(duplicate.filePath, duplicate.filename, duplicate.hash) {
+			// The file it copies failed to be indexed, the copy is picked up by the next scan
+			log.Infof("The file %s copies wasn't indexed, leaving it for the next scan", duplicate.filename)
 		}
 	}
-
-	return "", fmt.Errorf("could not find fd command. Please install fd, fdfind, or fd-find")
 }
 
-func (p *Processor) handleDuplicateFile(filePath, filename string) {
+// handleDuplicateFile applies the configured duplicate policy to a copy of the file indexed under hash.
+// It returns false without touching the copy when no file is indexed under hash yet.
+func (p *Processor) handleDuplicateFile(filePath, filename, hash string) bool {
+	canonicalID, found, err := p.repo.LookupHash(hash)
+	if err != nil {
+		log.Errorf("Error finding the indexed file for duplicate %s: %v", filename, err)
+		return true
+	}
+	if !found {
+		return false
+	}
+	canonical, err := p.repo.GetFileByID(canonicalID)
+	if err != nil {
+		log.Errorf("Error fetching the indexed file for duplicate %s: %v", filename, err)
+		return true
+	}
+	if canonical.Filename == filename {
+		// The file was found while it was being indexed, it is no copy of itself
+		return true
+	}
 	log.Warnf("Duplicate hash detected for %s", filename)
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		log.Errorf("Error getting file info for duplicate %s: %v", filename, err)
+		return true
+	}
 
-	duplicatesDir := filepath.Join(filepath.Dir(p.config.FolderPath), "duplicates")
-	if err := os.MkdirAll(duplicatesDir, 0755); err != nil {
-		log.Errorf("Error creating duplicates directory: %v", err)
-		return
+	policy := p.config.DuplicatePolicy
+	duplicatePath := filePath
+	switch policy {
+	case config.DuplicatesMove:
+		if err := os.MkdirAll(p.config.DuplicatesFolderPath, 0755); err != nil {
+			log.Errorf("Error creating duplicates directory: %v", err)
+			return true
+		}
+		duplicatePath, err = moveToFreeName(filePath, filepath.Join(p.config.DuplicatesFolderPath, filepath.Base(filename)))
+		if err != nil {
+			log.Errorf("Error moving duplicate file %s: %v", filename, err)
+			return true
+		}
+		log.Infof("Moved duplicate %s to %s", filename, duplicatePath)
+	case config.DuplicatesHardlink:
+		if err := linkToCanonical(filePath, filepath.Join(p.config.FolderPath, canonical.Filename)); err != nil {
+			log.Errorf("Error hard linking duplicate %s, leaving it in place: %v", filename, err)
+			policy = config.DuplicatesIndex
+		}
+	case config.DuplicatesDelete:
+		if err := os.Remove(filePath); err != nil {
+			log.Errorf("Error deleting duplicate file %s: %v", filename, err)
+			return true
+		}
+		log.Infof("Deleted duplicate %s of %s", filename, canonical.Filename)
+		return true
+	case config.DuplicatesIndex:
+	default:
+		log.Warnf("Unknown duplicate policy %q, leaving %s in place", policy, filename)
+		policy = config.DuplicatesIndex
+	}
+
+	err = p.repo.AddDuplicate(&kv.Duplicate{
+		Path:        duplicatePath,
+		CanonicalId: canonical.Id,
+		Hash:        hash,
+		Policy:      policy,
+		Size:        fileInfo.Size(),
+		FoundAt:     timestamppb.New(time.Now()),
+	})
+	if err != nil {
+		log.Errorf("Error recording duplicate %s: %v", filename, err)
+	}
+	return true
+}
+
+// linkToCanonical replaces a copy with a hard link to the indexed file so they share their data
+func linkToCanonical(filePath, canonicalPath string) error {
+	copyInfo, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	canonicalInfo, err := os.Stat(canonicalPath)
+	if err != nil {
+		return err
+	}
+	if os.SameFile(copyInfo, canonicalInfo) {
+		return nil
+	}
+	// Link under a hidden name first so the copy is never missing
+	tempPath := filepath.Join(filepath.Dir(filePath), TempFilePrefix+"link-"+filepath.Base(filePath))
+	if err := os.Link(canonicalPath, tempPath); err != nil {
+		return err
 	}
+	if err := os.Rename(tempPath, filePath); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
 
-	duplicatePath := filepath.Join(duplicatesDir, filename)
-	if err := os.Rename(filePath, duplicatePath); err != nil {
-		log.Errorf("Error moving duplicate file %s: %v", filename, err)
+// removeStaleDuplicates forgets duplicates that are gone, that lost their indexed file, or that got indexed themselves
+func (p *Processor) removeStaleDuplicates() {
+	duplicates, err := p.repo.GetDuplicates()
+	if err != nil {
+		log.Errorf("Error fetching duplicates: %v", err)
+		return
+	}
+	indexedNames, _, err := p.repo.FindAllFiles()
+	if err != nil {
+		log.Errorf("Error fetching existing files from repository: %v", err)
+		return
+	}
+	for _, duplicate := range duplicates {
+		_, statErr := os.Stat(duplicate.Path)
+		_, canonicalErr := p.repo.GetFileByID(duplicate.CanonicalId)
+		indexed := false
+		if rel, err := filepath.Rel(p.config.FolderPath, duplicate.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			_, indexed = indexedNames.Load(rel)
+		}
+		if statErr == nil && canonicalErr == nil && !indexed {
+			continue
+		}
+		log.Debugf("Removing stale duplicate record for %s", duplicate.Path)
+		if err := p.repo.DeleteDuplicate(duplicate.Path); err != nil {
+			log.Errorf("Error removing duplicate record for %s: %v", duplicate.Path, err)
+		}
 	}
 }
 
@@ -194,7 +426,7 @@ func (p *Processor) processFile(filePath string, existingFilesMap *sync.Map, exi
 	}
 
 	lastModified := fileInfo.ModTime().Unix()
-	filename := filepath.Base(filePath)
+	filename := p.relativeName(filePath)
 
 	// Early skipping of unmodified files
 	existingFileIDInterface, existsByName := existingFilesMap.Load(filename)
@@ -216,16 +448,43 @@ func (p *Processor) processFile(filePath string, existingFilesMap *sync.Map, exi
 		}
 	}
 
-	hash, err := p.handler.generateFileKey(filePath)
+	if p.skipFailed(filename, fileInfo) {
+		// An indexed file that fails to update keeps its record
+		existingFilesMap.Delete(filename)
+		return nil
+	}
+
+	hash, err := p.hashFile(filePath)
 	if err != nil {
 		return fmt.Errorf("error generating hash for %s: %v", filename, err)
 	}
 
 	if _, alreadyProcessed := processedHashes.Load(hash); alreadyProcessed {
 		log.Warnf("Found duplicate file: %s (hash: %s)", filename, hash)
-		p.handleDuplicateFile(filePath, filename)
+		if !p.handleDuplicateFile(filePath, filename, hash) {
+			// The file it copies is still in the batch
+			p.deferDuplicate(filePath, filename, hash)
+		}
 		return nil
 	}
+	if owner, busy := p.inFlight.LoadOrStore(hash, filename); busy {
+		processedHashes.Store(hash, true)
+		existingFilesMap.Delete(filename)
+		if owner == filename {
+			// An upload was moved here and is being indexed
+			log.Debugf("File %s is being ingested, skipping", filename)
+			return nil
+		}
+		log.Debugf("File %s is already being indexed, handling it as a duplicate once that is done", filename)
+		p.deferDuplicate(filePath, filename, hash)
+		return nil
+	}
+	queued := false
+	defer func() {
+		if !queued {
+			p.inFlight.Delete(hash)
+		}
+	}()
 
 	existingFileIDInterface, existsByHash := existingFilesHashesMap.Load(hash)
 	if existsByHash {
@@ -237,30 +496,53 @@ func (p *Processor) processFile(filePath string, existingFilesMap *sync.Map, exi
 		if err != nil {
 			return fmt.Errorf("error fetching file %s: %v", filename, err)
 		}
+		oldName := existingFile.Filename
+		if oldName != filename {
+			// A copy rather than a rename when the indexed file is still there
+			if p.unchangedAt(oldName, hash) {
+				log.Warnf("Found copy of %s: %s (hash: %s)", oldName, filename, hash)
+				p.handleDuplicateFile(filePath, filename, hash)
+				return nil
+			}
+			log.Infof("File %s was renamed to %s", oldName, filename)
+			existingFile, err = p.repo.RenameFile(existingFile.Id, filename)
+			if err != nil {
+				return fmt.Errorf("error renaming file %s to %s: %v", oldName, filename, err)
+			}
+			// The old name is gone from disk, it must not be taken for a deleted file
+			existingFilesMap.Delete(oldName)
+		}
 		log.Debugf("Updating existing file record for %s", filename)
-		existingFile.Filename = filename
 		existingFile.LastModified = lastModified
 		if err := p.repo.UpdateFile(existingFile); err != nil {
 			return fmt.Errorf("error updating file %s: %v", filename, err)
 		}
-		existingFilesMap.Delete(existingFile.Filename)
+		p.status.updated.Add(1)
+	} else if _, indexed, err := p.repo.LookupHash(hash); err != nil || indexed {
+		// Uploads are indexed while the scan runs, after the existing files were listed
+		if err != nil {
+			return fmt.Errorf("error looking up hash for %s: %v", filename, err)
+		}
+		log.Debugf("File %s was indexed since the scan started, skipping", filename)
 	} else {
 		log.Debugf("Processing new file %s", filename)
-		mimeType, err := getFileMimeType(filePath)
-		if err != nil {
-			return fmt.Errorf("error detecting mime type for %s: %v", filename, err)
+		media, mediaFile := p.detectMedia(filePath)
+		if media == nil {
+			return fmt.Errorf("unsupported file type for %s", filePath)
 		}
 		newFile := &kv.File{
 			Filename:     filename,
 			Hash:         hash,
 			LastModified: lastModified,
-			MimeType:     string(mimeType),
 			CreatedAt:    timestamppb.New(time.Now()),
 			Size:         fileInfo.Size(),
 		}
-		if err := p.processNewFile(filePath, newFile, mimeType); err != nil {
+		if err := p.prepareNewFile(media, mediaFile, newFile); err != nil {
 			return fmt.Errorf("error processing new file %s: %v", filename, err)
 		}
+		// The batch releases the hash once the file is written
+		p.batch.add(filePath, newFile)
+		queued = true
 	}
 
 	processedHashes.Store(hash, true)
@@ -268,8 +550,144 @@ func (p *Processor) processFile(filePath string, existingFilesMap *sync.Map, exi
 	return nil
 }
 
+// unchangedAt tells whether the file at filename still has the content indexed under hash. Another
+// file may have taken the name of one that was renamed.
+func (p *Processor) unchangedAt(filename, hash string) bool {
+	filePath := filepath.Join(p.config.FolderPath, filename)
+	if _, err := os.Stat(filePath); err != nil {
+		return false
+	}
+	current, err := p.hashFile(filePath)
+	if err != nil {
+		log.Warnf("Error hashing %s, taking it for changed: %v", filename, err)
+		return false
+	}
+	return current == hash
+}
+
+// DuplicateError is returned by Ingest when the content is already in the library
+type DuplicateError struct {
+	Existing *kv.File
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("file is a duplicate of %s", e.Existing.Filename)
+}
+
+// ErrUnsupported is returned by Ingest for files that are neither images nor videos
+var ErrUnsupported = errors.New("unsupported file type")
+
+// ErrExcluded is returned by Ingest for files the scan filters would leave out
+var ErrExcluded = errors.New("file is excluded from the library")
+
+// Ingest checks the file at srcPath against the library, moves it to targetPath, or a free
+// variant of it when the name is taken, and indexes it right away instead of waiting for the
+// next scan. srcPath is left in place when an error is returned.
+func (p *Processor) Ingest(srcPath, targetPath string) (*kv.File, error) {
+	hash, err := p.hashFile(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("error generating hash for %s: %w", srcPath, err)
+	}
+	if _, busy := p.inFlight.LoadOrStore(hash, srcPath); busy {
+		return nil, fmt.Errorf("a file with the same content is already being indexed")
+	}
+	defer p.inFlight.Delete(hash)
+
+	existingID, indexed, err := p.repo.LookupHash(hash)
+	if err != nil {
+		return nil, fmt.Errorf("error looking up hash for %s: %w", srcPath, err)
+	}
+	if indexed {
+		existing, err := p.repo.GetFileByID(existingID)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching file %d: %w", existingID, err)
+		}
+		return nil, &DuplicateError{Existing: existing}
+	}
+
+	media, mediaFile := p.detectMedia(srcPath)
+	if media == nil {
+		return nil, ErrUnsupported
+	}
+
+	srcInfo, err := os.Stat(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file info for %s: %w", srcPath, err)
+	}
+	if reason := newScanFilter(p.config).skip(targetPath, srcInfo); reason != "" {
+		return nil, fmt.Errorf("%w: %s", ErrExcluded, reason)
+	}
+
+	filePath, err := moveToFreeName(srcPath, targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("error moving %s into the library: %w", srcPath, err)
+	}
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file info for %s: %w", filePath, err)
+	}
+	filename := p.relativeName(filePath)
+	// A scan reaching the file before it is stored must skip it rather than take it for a copy
+	p.inFlight.Store(hash, filename)
+	newFile := &kv.File{
+		Filename:     filename,
+		Hash:         hash,
+		LastModified: fileInfo.ModTime().Unix(),
+		CreatedAt:    timestamppb.New(time.Now()),
+		Size:         fileInfo.Size(),
+	}
+	// The file is in the library now, if this fails the next scan picks it up again
+	mediaFile.Path = filePath
+	if err := p.prepareNewFile(media, mediaFile, newFile); err != nil {
+		return nil, fmt.Errorf("error processing new file %s: %w", filename, err)
+	}
+	if err := p.repo.AddFile(newFile); err != nil {
+		return nil, fmt.Errorf("error storing file %s: %w", filename, err)
+	}
+	// Live Photos are uploaded as two files, the second one completes the pair
+	p.pairMotion(newFile)
+	log.Infof("Ingested %s", filename)
+	return newFile, nil
+}
+
+// moveToFreeName moves srcPath to targetPath, appending _1, _2... to the name until it doesn't
+// overwrite anything. Linking fails when the name exists so two uploads can't claim the same one.
+func moveToFreeName(srcPath, targetPath string) (string, error) {
+	ext := filepath.Ext(targetPath)
+	base := strings.TrimSuffix(targetPath, ext)
+	candidate := targetPath
+	for i := 1; ; i++ {
+		err := os.Link(srcPath, candidate)
+		if err == nil {
+			return candidate, os.Remove(srcPath)
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			// Some filesystems don't support hard links
+			_, statErr := os.Lstat(candidate)
+			if errors.Is(statErr, fs.ErrNotExist) {
+				return candidate, os.Rename(srcPath, candidate)
+			}
+			// Without knowing whether the name is taken, like in a folder we can't search, no name would do
+			if statErr != nil {
+				return "", fmt.Errorf("error moving %s to %s: %w", srcPath, candidate, err)
+			}
+		}
+		candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+}
+
+// relativeName is the key a file is indexed under, its path relative to the library folder
+func (p *Processor) relativeName(filePath string) string {
+	rel, err := filepath.Rel(p.config.FolderPath, filePath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.Base(filePath)
+	}
+	return rel
+}
+
 func (p *Processor) Shutdown(ctx context.Context) {
 	log.Info("Initiating graceful shutdown of processor")
+	p.regeneration.stop()
 
 	// Terminate external processes
 	p.processes.Range(func(key, value interface{}) bool {
@@ -302,33 +720,20 @@ func (p *Processor) Shutdown(ctx context.Context) {
 	log.Info("Processor shutdown completed")
 }
 
-func (p *Processor) processNewFile(filePath string, newFile *kv.File, mimeType utils.MimeType) error {
-	switch mimeType {
-	case utils.MimeTypeImage:
-		image, err := p.handler.handleNewImage(p, filePath)
-		if err != nil {
-			return fmt.Errorf("error processing image %s: %w", filePath, err)
-		}
-		newFile.Media = &kv.File_Image{Image: image}
-	case utils.MimeTypeVideo:
-		video, err := p.handler.handleNewVideo(p, filePath)
-		if err != nil {
-			return fmt.Errorf("error processing video %s: %w", filePath, err)
-		}
-		newFile.Media = &kv.File_Video{Video: video}
-	default:
-		return fmt.Errorf("unsupported file type for %s", filePath)
-	}
-	return p.repo.AddFile(newFile)
-}
-
 func (p *Processor) removeNonExistentFiles(existingFilesMap *sync.Map) {
 	log.Info("Removing non-existent files from repository")
 	existingFilesMap.Range(func(key, value interface{}) bool {
 		filename, _ := key.(string)
 		fileID, _ := value.(uint64)
+		// Files renamed or moved through the API during the scan are still listed under their old name
+		if file, err := p.repo.GetFileByID(fileID); err == nil && file.Filename != filename {
+			log.Debugf("File %s was renamed to %s during the scan, keeping it", filename, file.Filename)
+			return true
+		}
 		if err := p.repo.DeleteFile(fileID); err != nil {
 			log.Errorf("Error deleting file %s: %v", filename, err)
+		} else {
+			p.status.deleted.Add(1)
 		}
 		return true
 	})
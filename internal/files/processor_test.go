@@ -0,0 +1,752 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"picshow/internal/cache"
+	"picshow/internal/config"
+	"picshow/internal/kv"
+	"picshow/internal/utils"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newTestProcessor opens a fresh database and identifies files by their content
+func newTestProcessor(t *testing.T) (*Processor, *kv.Repository) {
+	t.Helper()
+	root := t.TempDir()
+	cfg := &config.Config{
+		FolderPath:           filepath.Join(root, "library"),
+		DBPath:               filepath.Join(root, "db"),
+		CacheSizeMB:          1,
+		UploadFolder:         "inbox",
+		DuplicatePolicy:      config.DuplicatesMove,
+		DuplicatesFolderPath: filepath.Join(root, "duplicates"),
+		MaxFailedAttempts:    2,
+	}
+	if err := os.MkdirAll(cfg.UploadPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	db, err := kv.GetDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := cache.NewCache(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := kv.NewRepository(db, c, cfg)
+	t.Cleanup(func() { repo.Close() })
+
+	p := NewProcessor(cfg, repo, nil, 10, 1)
+	p.hashFile = func(filePath string) (string, error) {
+		content, err := os.ReadFile(filePath)
+		return string(content), err
+	}
+	return p, repo
+}
+
+func writeFile(t *testing.T, p *Processor, name, content string) string {
+	t.Helper()
+	filePath := filepath.Join(p.config.FolderPath, name)
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+// indexFile stores a favorite image as a previous scan would have
+func indexFile(t *testing.T, repo *kv.Repository, name, hash string) *kv.File {
+	t.Helper()
+	file := &kv.File{
+		Filename:  name,
+		Hash:      hash,
+		MimeType:  "image",
+		CreatedAt: timestamppb.Now(),
+		Media:     &kv.File_Image{Image: &kv.Image{Width: 640, Height: 480}},
+	}
+	if err := repo.AddFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ToggleFileFavorite(file.Id); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// scan runs processFile over the given paths followed by the cleanup of a complete scan
+func scan(t *testing.T, p *Processor, repo *kv.Repository, paths ...string) {
+	t.Helper()
+	existingFilesMap, existingFilesHashesMap, err := repo.FindAllFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	processedHashes := &sync.Map{}
+	for _, filePath := range paths {
+		if err := p.processFile(filePath, existingFilesMap, existingFilesHashesMap, processedHashes); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.batch.flush(); err != nil {
+		t.Fatal(err)
+	}
+	p.handleDeferredDuplicates()
+	p.removeNonExistentFiles(existingFilesMap)
+}
+
+func indexedNames(t *testing.T, repo *kv.Repository) map[string]uint64 {
+	t.Helper()
+	byName, _, err := repo.FindAllFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]uint64)
+	byName.Range(func(key, value interface{}) bool {
+		names[key.(string)] = value.(uint64)
+		return true
+	})
+	return names
+}
+
+func assertSameFile(t *testing.T, repo *kv.Repository, original *kv.File, wantName string) {
+	t.Helper()
+	file, err := repo.GetFileByID(original.Id)
+	if err != nil {
+		t.Fatalf("file %d is gone: %v", original.Id, err)
+	}
+	if file.Filename != wantName {
+		t.Errorf("Filename = %q, want %q", file.Filename, wantName)
+	}
+	if file.GetImage().GetWidth() != 640 {
+		t.Errorf("image metadata was lost: %+v", file.GetImage())
+	}
+	favorite, err := repo.IsFileFavorite(original.Id)
+	if err != nil || !favorite {
+		t.Errorf("file %d is no longer a favorite (err: %v)", original.Id, err)
+	}
+	names := indexedNames(t, repo)
+	if len(names) != 1 || names[wantName] != original.Id {
+		t.Errorf("fileName index = %v, want only %s -> %d", names, wantName, original.Id)
+	}
+}
+
+func TestProcessFileDetectsRename(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	original := indexFile(t, repo, "old.jpg", "content")
+	newPath := writeFile(t, p, "new.jpg", "content")
+
+	scan(t, p, repo, newPath)
+
+	assertSameFile(t, repo, original, "new.jpg")
+}
+
+func TestProcessFileDetectsRenameOntoReusedName(t *testing.T) {
+	for _, order := range []string{"renamed first", "new file first"} {
+		t.Run(order, func(t *testing.T) {
+			p, repo := newTestProcessor(t)
+			p.RegisterMediaHandler(&textHandler{})
+			original := indexFile(t, repo, "a.txt", "content")
+			// a.txt was renamed to b.txt and a new a.txt took its name
+			renamedPath := writeFile(t, p, "b.txt", "content")
+			newPath := writeFile(t, p, "a.txt", "other")
+
+			if order == "renamed first" {
+				scan(t, p, repo, renamedPath, newPath)
+			} else {
+				scan(t, p, repo, newPath, renamedPath)
+			}
+
+			names := indexedNames(t, repo)
+			if len(names) != 2 || names["b.txt"] != original.Id || names["a.txt"] == original.Id {
+				t.Errorf("indexed %v, want b.txt -> %d and a new a.txt", names, original.Id)
+			}
+			if favorite, err := repo.IsFileFavorite(original.Id); err != nil || !favorite {
+				t.Errorf("renamed file is no longer a favorite (err: %v)", err)
+			}
+			if _, err := os.Stat(renamedPath); err != nil {
+				t.Errorf("renamed file was taken for a copy: %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessFileDetectsMoveToUploadFolder(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	original := indexFile(t, repo, "photo.jpg", "content")
+	newPath := writeFile(t, p, filepath.Join("inbox", "photo.jpg"), "content")
+
+	scan(t, p, repo, newPath)
+
+	assertSameFile(t, repo, original, filepath.Join("inbox", "photo.jpg"))
+}
+
+func TestProcessFileKeepsOriginalOfCopy(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	original := indexFile(t, repo, "photo.jpg", "content")
+	originalPath := writeFile(t, p, "photo.jpg", "content")
+	copyPath := writeFile(t, p, "copy.jpg", "content")
+
+	scan(t, p, repo, copyPath, originalPath)
+
+	assertSameFile(t, repo, original, "photo.jpg")
+	if _, err := os.Stat(copyPath); !os.IsNotExist(err) {
+		t.Errorf("copy was left in the library (err: %v)", err)
+	}
+}
+
+func TestProcessFileRenameSurvivesRescan(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	original := indexFile(t, repo, "old.jpg", "content")
+	newPath := writeFile(t, p, "new.jpg", "content")
+
+	scan(t, p, repo, newPath)
+	scan(t, p, repo, newPath)
+
+	assertSameFile(t, repo, original, "new.jpg")
+}
+
+func TestDuplicateMoveKeepsEarlierCopies(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	original := indexFile(t, repo, "photo.jpg", "content")
+	originalPath := writeFile(t, p, "photo.jpg", "content")
+
+	// A second run finds nothing left to move
+	for i := 0; i < 2; i++ {
+		copyPath := writeFile(t, p, "copy.jpg", "content")
+		scan(t, p, repo, copyPath, originalPath)
+	}
+
+	duplicates, err := repo.GetDuplicates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(duplicates) != 2 {
+		t.Fatalf("recorded %d duplicates, want 2", len(duplicates))
+	}
+	for _, duplicate := range duplicates {
+		if duplicate.CanonicalId != original.Id {
+			t.Errorf("duplicate %s points to %d, want %d", duplicate.Path, duplicate.CanonicalId, original.Id)
+		}
+		if _, err := os.Stat(duplicate.Path); err != nil {
+			t.Errorf("duplicate %s is missing: %v", duplicate.Path, err)
+		}
+	}
+}
+
+func TestMoveToFreeNameFailsOnUnknownTarget(t *testing.T) {
+	p, _ := newTestProcessor(t)
+	srcPath := writeFile(t, p, "photo.jpg", "content")
+	// A file in place of the target folder, whether the name is free can't be told
+	notFolder := writeFile(t, p, "folder", "not a folder")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := moveToFreeName(srcPath, filepath.Join(notFolder, "photo.jpg"))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("move into a file succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("moveToFreeName kept trying other names")
+	}
+	if _, err := os.Stat(srcPath); err != nil {
+		t.Errorf("source is gone: %v", err)
+	}
+}
+
+func TestFailedFileWaitsForRetry(t *testing.T) {
+	p, _ := newTestProcessor(t)
+	filePath := writeFile(t, p, "broken.jpg", "content")
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.recordFailure(filePath, newToolError(errors.New("exit status 1"), "corrupt image\n"))
+	failure, err := p.repo.GetFailure("broken.jpg")
+	if err != nil || failure == nil {
+		t.Fatalf("failure was not recorded (err: %v)", err)
+	}
+	if failure.Attempts != 1 || failure.Stderr != "corrupt image" {
+		t.Errorf("recorded %d attempts with stderr %q", failure.Attempts, failure.Stderr)
+	}
+	if !p.skipFailed("broken.jpg", info) {
+		t.Error("failed file was retried before its backoff elapsed")
+	}
+
+	if err := os.WriteFile(filePath, []byte("fixed content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err = os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.skipFailed("broken.jpg", info) {
+		t.Error("changed file was not retried")
+	}
+}
+
+func TestWalkerDepthAndSymlinks(t *testing.T) {
+	p, _ := newTestProcessor(t)
+	root := p.config.FolderPath
+	for _, dir := range []string{"a/b", "inbox/c", ".hidden"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"top.jpg", "a/one.jpg", "a/b/two.jpg", "inbox/up.jpg", "inbox/c/deep.jpg", ".hidden/h.jpg"} {
+		writeFile(t, p, name, name)
+	}
+	// Links into the library would list the same files twice, links out of it are followed
+	if err := os.Symlink(filepath.Join(root, "a"), filepath.Join(root, "inner")); err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "out.jpg"), []byte("out"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func() []string {
+		var found []string
+		_, err := p.discover(context.Background(), "", "", func(filePath string) error {
+			rel, _ := filepath.Rel(root, filePath)
+			found = append(found, rel)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(found)
+		return found
+	}
+
+	p.config.MaxDepth = 1
+	if got, want := list(), []string{"inbox/up.jpg", "top.jpg"}; !slices.Equal(got, want) {
+		t.Errorf("depth 1 found %v, want %v", got, want)
+	}
+
+	p.config.MaxDepth = 2
+	p.config.FollowSymlinks = true
+	want := []string{"a/one.jpg", "inbox/c/deep.jpg", "inbox/up.jpg", "link/out.jpg", "top.jpg"}
+	if got := list(); !slices.Equal(got, want) {
+		t.Errorf("depth 2 with symlinks found %v, want %v", got, want)
+	}
+}
+
+func TestDiscoverReportsUnreadableFolders(t *testing.T) {
+	p, _ := newTestProcessor(t)
+	p.config.FollowSymlinks = true
+	root := p.config.FolderPath
+	writeFile(t, p, "top.jpg", "top")
+	discover := func() (bool, error) {
+		return p.discover(context.Background(), "", "", func(string) error { return nil })
+	}
+
+	// The upload folder is only made on the first upload
+	if err := os.Remove(p.config.UploadPath()); err != nil {
+		t.Fatal(err)
+	}
+	if complete, err := discover(); err != nil || !complete {
+		t.Errorf("discover without an upload folder = %v, %v, want a complete walk", complete, err)
+	}
+
+	// A link that can't be resolved hides whatever it pointed to
+	if err := os.Symlink(filepath.Join(root, "loop"), filepath.Join(root, "loop")); err != nil {
+		t.Fatal(err)
+	}
+	if complete, err := discover(); err != nil || complete {
+		t.Errorf("discover with a symlink loop = %v, %v, want an incomplete walk", complete, err)
+	}
+
+	// A library that is gone, like an unmounted disk, must not look empty
+	if err := os.RemoveAll(root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := discover(); err == nil {
+		t.Error("discover of a missing library succeeded")
+	}
+}
+
+func TestFdListsNestedRootsOnce(t *testing.T) {
+	p, _ := newTestProcessor(t)
+	p.config.UseFd = true
+	p.config.MaxDepth = -1
+	fdCommand := p.fdCommand()
+	if fdCommand == "" {
+		t.Skip("fd is not installed")
+	}
+	root := p.config.FolderPath
+	for _, name := range []string{"top.jpg", "inbox/up.jpg"} {
+		writeFile(t, p, name, name)
+	}
+
+	var found []string
+	_, err := p.discover(context.Background(), fdCommand, "", func(filePath string) error {
+		rel, _ := filepath.Rel(root, filePath)
+		found = append(found, rel)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(found)
+	if want := []string{"inbox/up.jpg", "top.jpg"}; !slices.Equal(found, want) {
+		t.Errorf("fd found %v, want %v", found, want)
+	}
+}
+
+func TestResumedWalkSkipsProcessedFiles(t *testing.T) {
+	p, _ := newTestProcessor(t)
+	root := p.config.FolderPath
+	p.config.MaxDepth = -1
+	if err := os.MkdirAll(filepath.Join(root, "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"0.jpg", "a/one.jpg", "a/two.jpg", "a.jpg", "b.jpg", "inbox/up.jpg"} {
+		writeFile(t, p, name, name)
+	}
+
+	list := func(after string) []string {
+		var found []string
+		_, err := p.discover(context.Background(), "", after, func(filePath string) error {
+			rel, _ := filepath.Rel(root, filePath)
+			found = append(found, rel)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+
+	// Files after a folder's contents in name order, the upload folder is its own root
+	if got, want := list(filepath.Join(root, "a/one.jpg")), []string{"a/two.jpg", "a.jpg", "b.jpg", "inbox/up.jpg"}; !slices.Equal(got, want) {
+		t.Errorf("resumed after a/one.jpg found %v, want %v", got, want)
+	}
+	if got, want := list(filepath.Join(root, "inbox/up.jpg")), []string(nil); !slices.Equal(got, want) {
+		t.Errorf("resumed after inbox/up.jpg found %v, want %v", got, want)
+	}
+
+	// The cursor only moves past files once everything listed before them is done
+	progress := p.startProgress(true)
+	for _, name := range []string{"0.jpg", "a/one.jpg", "a/two.jpg"} {
+		progress.discovered(name)
+	}
+	progress.finished("a/one.jpg")
+	if progress.checkpoint.Cursor != "" {
+		t.Errorf("cursor moved to %s before 0.jpg was done", progress.checkpoint.Cursor)
+	}
+	progress.finished("0.jpg")
+	if progress.checkpoint.Cursor != "a/one.jpg" {
+		t.Errorf("cursor = %q, want a/one.jpg", progress.checkpoint.Cursor)
+	}
+	progress.save()
+	if resumed := p.startProgress(true); resumed.resumedFrom != "a/one.jpg" {
+		t.Errorf("resumed from %q, want a/one.jpg", resumed.resumedFrom)
+	}
+}
+
+func TestBatchWritesFilesTogether(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	batch := newFileBatch(p, 3)
+	for _, name := range []string{"a.jpg", "b.jpg"} {
+		p.inFlight.Store(name, name)
+		batch.add(writeFile(t, p, name, name), &kv.File{
+			Filename: name,
+			Hash:     name,
+			Media:    &kv.File_Image{Image: &kv.Image{}},
+		})
+	}
+	if names := indexedNames(t, repo); len(names) != 0 {
+		t.Fatalf("files were written before the batch was full: %v", names)
+	}
+	if _, busy := p.inFlight.Load("a.jpg"); !busy {
+		t.Error("queued file released its hash before it was written")
+	}
+
+	batch.add(writeFile(t, p, "c.jpg", "c.jpg"), &kv.File{Filename: "c.jpg", Hash: "c.jpg", Media: &kv.File_Video{Video: &kv.Video{}}})
+	if names := indexedNames(t, repo); len(names) != 3 {
+		t.Errorf("full batch wrote %v, want 3 files", names)
+	}
+	if _, busy := p.inFlight.Load("a.jpg"); busy {
+		t.Error("written file kept its hash in flight")
+	}
+	stats, err := repo.GetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Count != 3 || stats.ImageCount != 2 || stats.VideoCount != 1 {
+		t.Errorf("stats = %+v, want 3 files, 2 images and 1 video", stats)
+	}
+}
+
+func TestFailedBatchWritesFilesOneAtATime(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	batch := newFileBatch(p, 2)
+	batch.add(writeFile(t, p, "good.jpg", "good"), &kv.File{Filename: "good.jpg", Hash: "good", Media: &kv.File_Image{Image: &kv.Image{}}})
+	// Its hash is too long for a key, it fails whatever batch it is in
+	batch.add(writeFile(t, p, "bad.jpg", "bad"), &kv.File{Filename: "bad.jpg", Hash: strings.Repeat("x", 1<<16), Media: &kv.File_Image{Image: &kv.Image{}}})
+
+	if names := indexedNames(t, repo); len(names) != 1 || names["good.jpg"] == 0 {
+		t.Errorf("indexed %v, want only good.jpg", names)
+	}
+	if failure, err := repo.GetFailure("good.jpg"); err != nil || failure != nil {
+		t.Errorf("good.jpg was recorded as a failure: %v (err: %v)", failure, err)
+	}
+	if failure, err := repo.GetFailure("bad.jpg"); err != nil || failure == nil {
+		t.Errorf("bad.jpg wasn't recorded as a failure (err: %v)", err)
+	}
+}
+
+// textHandler indexes text files as images with a fixed size, to test the media registry
+type textHandler struct {
+	probed bool
+}
+
+func (h *textHandler) Type() utils.MimeType { return utils.MimeTypeImage }
+
+func (h *textHandler) Detect(file *MediaFile) bool { return filepath.Ext(file.Path) == ".txt" }
+
+func (h *textHandler) Probe(file *MediaFile) error {
+	h.probed = true
+	file.Width, file.Height = 100, 50
+	return nil
+}
+
+func (h *textHandler) Thumbnail(file *MediaFile) error {
+	file.Thumbnail = []byte("thumbnail")
+	return nil
+}
+
+func (h *textHandler) Metadata(file *MediaFile, record *kv.File) error {
+	record.Media = &kv.File_Image{Image: &kv.Image{Width: file.Width, Height: file.Height}}
+	return nil
+}
+
+func TestIngestUsesRegisteredMediaHandler(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	media := &textHandler{}
+	p.RegisterMediaHandler(media)
+	srcPath := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(srcPath, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := p.Ingest(srcPath, filepath.Join(p.config.UploadPath(), "notes.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !media.probed {
+		t.Error("registered handler wasn't used")
+	}
+	stored, err := repo.GetFileByID(file.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.MimeType != utils.MimeTypeImage.String() || stored.GetImage().GetWidth() != 100 {
+		t.Errorf("stored %+v", stored)
+	}
+	// The thumbnail is kept apart from the record, as the large JPEG
+	thumbnail, found, err := repo.GetThumbnail(stored.Hash, utils.ThumbnailLarge.String(), "jpeg")
+	if err != nil || !found || string(thumbnail) != "thumbnail" {
+		t.Errorf("thumbnail = %q, found %v, err %v", thumbnail, found, err)
+	}
+
+	if _, err := p.Ingest(writeFile(t, p, "data.bin", "data"), filepath.Join(p.config.UploadPath(), "data.bin")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Ingest of an unknown type returned %v, want ErrUnsupported", err)
+	}
+}
+
+func TestDuplicateOfBatchedFileIsMoved(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	p.RegisterMediaHandler(&textHandler{})
+	originalPath := writeFile(t, p, "notes.txt", "notes")
+	copyPath := writeFile(t, p, "copy.txt", "notes")
+	// A copy found while the file it copies is being indexed by an upload
+	p.inFlight.Store("upload", "upload.txt")
+	busyPath := writeFile(t, p, "busy.txt", "upload")
+
+	scan(t, p, repo, originalPath, copyPath, busyPath)
+
+	names := indexedNames(t, repo)
+	if _, indexed := names["notes.txt"]; len(names) != 1 || !indexed {
+		t.Errorf("indexed %v, want only notes.txt", names)
+	}
+	duplicates, err := repo.GetDuplicates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(duplicates) != 1 || duplicates[0].CanonicalId != names["notes.txt"] {
+		t.Fatalf("duplicates = %v, want the copy of notes.txt", duplicates)
+	}
+	if _, err := os.Stat(copyPath); !os.IsNotExist(err) {
+		t.Errorf("copy was left in the library (err: %v)", err)
+	}
+	// The upload never finished, its copy waits for the next scan
+	if _, err := os.Stat(busyPath); err != nil {
+		t.Errorf("copy of a file that wasn't indexed was moved: %v", err)
+	}
+}
+
+// blockingHandler holds Probe until released, to run a scan while an upload is being indexed
+type blockingHandler struct {
+	textHandler
+	probing, release chan struct{}
+}
+
+func (h *blockingHandler) Probe(file *MediaFile) error {
+	close(h.probing)
+	<-h.release
+	return h.textHandler.Probe(file)
+}
+
+func TestScanDuringIngestKeepsUpload(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	media := &blockingHandler{probing: make(chan struct{}), release: make(chan struct{})}
+	p.RegisterMediaHandler(media)
+	srcPath := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(srcPath, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	targetPath := filepath.Join(p.config.UploadPath(), "notes.txt")
+
+	ingested := make(chan error, 1)
+	go func() {
+		_, err := p.Ingest(srcPath, targetPath)
+		ingested <- err
+	}()
+	// The upload is in the library but not stored yet
+	<-media.probing
+	existingFilesMap, existingFilesHashesMap, err := repo.FindAllFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.processFile(targetPath, existingFilesMap, existingFilesHashesMap, &sync.Map{}); err != nil {
+		t.Fatal(err)
+	}
+	close(media.release)
+	if err := <-ingested; err != nil {
+		t.Fatal(err)
+	}
+	if err := p.batch.flush(); err != nil {
+		t.Fatal(err)
+	}
+	p.handleDeferredDuplicates()
+	p.removeNonExistentFiles(existingFilesMap)
+
+	if _, err := os.Stat(targetPath); err != nil {
+		t.Errorf("upload was moved out of the library: %v", err)
+	}
+	names := indexedNames(t, repo)
+	if _, indexed := names["inbox/notes.txt"]; len(names) != 1 || !indexed {
+		t.Errorf("indexed %v, want only inbox/notes.txt", names)
+	}
+	duplicates, err := repo.GetDuplicates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(duplicates) != 0 {
+		t.Errorf("duplicates = %v, want none", duplicates)
+	}
+}
+
+func TestLivePhotoPairing(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	files := []*kv.File{
+		{Filename: "IMG_1.HEIC", Hash: "still", Media: &kv.File_Image{Image: &kv.Image{}}},
+		{Filename: "IMG_1.MOV", Hash: "motion", Media: &kv.File_Video{Video: &kv.Video{Length: 2}}},
+		{Filename: "IMG_2.JPG", Hash: "photo", Media: &kv.File_Image{Image: &kv.Image{}}},
+		{Filename: "IMG_2.MOV", Hash: "clip", Media: &kv.File_Video{Video: &kv.Video{Length: 60}}},
+	}
+	if err := repo.AddBatch(files); err != nil {
+		t.Fatal(err)
+	}
+	p.pairMotionPhotos()
+
+	still, err := repo.GetFileByID(files[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if videoID := still.GetImage().PairedVideo(); !still.GetImage().Motion || videoID == nil || *videoID != files[1].Id {
+		t.Errorf("still = %+v, want the motion of video %d", still.GetImage(), files[1].Id)
+	}
+	if photo, _ := repo.GetFileByID(files[2].Id); photo.GetImage().Motion {
+		t.Error("a long video was paired with a photo")
+	}
+	stats, err := repo.GetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Count != 3 || stats.VideoCount != 1 {
+		t.Errorf("stats = %+v, want 3 files and 1 video", stats)
+	}
+
+	// Without its still the video is a file of its own again
+	if err := repo.DeleteFile(files[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	lists, err := repo.GetAllFileIds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(lists.VideoFileIds, files[1].Id) || len(lists.MotionFileIds) != 0 {
+		t.Errorf("lists = %+v, want video %d listed again", lists, files[1].Id)
+	}
+	if stats, _ := repo.GetStats(); stats.Count != 3 || stats.VideoCount != 2 {
+		t.Errorf("stats = %+v, want 3 files and 2 videos", stats)
+	}
+}
+
+func TestRegenerateSelectsSizeMismatch(t *testing.T) {
+	p, repo := newTestProcessor(t)
+	p.config.MaxThumbnailSize = 400
+	var thumbnails []*kv.Thumbnail
+	for _, size := range utils.ThumbnailSizes {
+		thumbnails = append(thumbnails, &kv.Thumbnail{Size: size.String()})
+	}
+	files := []*kv.File{
+		{Filename: "current.jpg", Hash: "current", MimeType: "image", Thumbnails: thumbnails,
+			Media: &kv.File_Image{Image: &kv.Image{Width: 800, Height: 600, ThumbnailWidth: 400, ThumbnailHeight: 300}}},
+		{Filename: "smaller.jpg", Hash: "smaller", MimeType: "image", Thumbnails: thumbnails,
+			Media: &kv.File_Image{Image: &kv.Image{Width: 800, Height: 600, ThumbnailWidth: 200, ThumbnailHeight: 150}}},
+		{Filename: "sizeless.mp4", Hash: "sizeless", MimeType: "video",
+			Media: &kv.File_Video{Video: &kv.Video{Width: 600, Height: 800, ThumbnailWidth: 300, ThumbnailHeight: 400}}},
+	}
+	if err := repo.AddBatch(files); err != nil {
+		t.Fatal(err)
+	}
+
+	selected, err := p.selectForRegeneration(RegenerateFilter{SizeMismatch: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range selected {
+		names = append(names, file.Filename)
+	}
+	slices.Sort(names)
+	if want := []string{"sizeless.mp4", "smaller.jpg"}; !slices.Equal(names, want) {
+		t.Errorf("selected %v, want %v", names, want)
+	}
+
+	selected, err = p.selectForRegeneration(RegenerateFilter{Type: "video", IDs: []uint64{files[0].Id, files[2].Id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 1 || selected[0].Filename != "sizeless.mp4" {
+		t.Errorf("selected %v, want only sizeless.mp4", selected)
+	}
+}
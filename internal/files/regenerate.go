@@ -0,0 +1,283 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"picshow/internal/kv"
+	"picshow/internal/utils"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrRegenerationRunning is returned when thumbnails are regenerated while a regeneration is in progress
+var ErrRegenerationRunning = errors.New("thumbnails are already being regenerated")
+
+// RegenerateFilter picks the files whose thumbnails are made again, the zero value picks all of them.
+// The filters that are set must all match.
+type RegenerateFilter struct {
+	// Type only takes files of this type, like "image"
+	Type string `json:"type"`
+	// SizeMismatch only takes files whose thumbnails don't match MaxThumbnailSize or miss a size
+	SizeMismatch bool `json:"size_mismatch"`
+	// IDs only takes these files
+	IDs []uint64 `json:"ids"`
+}
+
+// Validate checks that the filter names a type files are indexed as
+func (f RegenerateFilter) Validate() error {
+	switch utils.MimeType(f.Type) {
+	case "", utils.MimeTypeImage, utils.MimeTypeVideo, utils.MimeTypeAudio:
+		return nil
+	}
+	return fmt.Errorf("unknown file type %q, expected image, video or audio", f.Type)
+}
+
+// RegenerationStatus is a snapshot of the progress of the current or last regeneration
+type RegenerationStatus struct {
+	Running    bool       `json:"running"`
+	Total      int64      `json:"total"`
+	Processed  int64      `json:"processed"`
+	Failed     int64      `json:"failed"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// regenerationStatus tracks a regeneration, counters are updated by the workers without locking
+type regenerationStatus struct {
+	total     atomic.Int64
+	processed atomic.Int64
+	failed    atomic.Int64
+
+	mu         sync.Mutex
+	cancel     context.CancelFunc
+	running    bool
+	startedAt  time.Time
+	finishedAt time.Time
+	lastError  string
+}
+
+func (s *regenerationStatus) start() {
+	s.total.Store(0)
+	s.processed.Store(0)
+	s.failed.Store(0)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = true
+	s.startedAt = time.Now()
+	s.finishedAt = time.Time{}
+	s.lastError = ""
+}
+
+func (s *regenerationStatus) finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+	s.finishedAt = time.Now()
+	if err != nil {
+		s.lastError = err.Error()
+	}
+}
+
+// stop cancels the regeneration running in the background, if any
+func (s *regenerationStatus) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+func (s *regenerationStatus) snapshot() RegenerationStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := RegenerationStatus{
+		Running:   s.running,
+		Total:     s.total.Load(),
+		Processed: s.processed.Load(),
+		Failed:    s.failed.Load(),
+		LastError: s.lastError,
+	}
+	if !s.startedAt.IsZero() {
+		startedAt := s.startedAt
+		status.StartedAt = &startedAt
+	}
+	if !s.finishedAt.IsZero() {
+		finishedAt := s.finishedAt
+		status.FinishedAt = &finishedAt
+	}
+	return status
+}
+
+// RegenerationStatus returns the progress of the current or last thumbnail regeneration
+func (p *Processor) RegenerationStatus() RegenerationStatus {
+	return p.regeneration.snapshot()
+}
+
+// StartRegeneration regenerates thumbnails in the background until done or until Shutdown,
+// ErrRegenerationRunning is returned when a regeneration is in progress
+func (p *Processor) StartRegeneration(filter RegenerateFilter) error {
+	if !p.regenerating.TryLock() {
+		return ErrRegenerationRunning
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.regeneration.start()
+	p.regeneration.mu.Lock()
+	p.regeneration.cancel = cancel
+	p.regeneration.mu.Unlock()
+	go func() {
+		defer p.regenerating.Unlock()
+		defer cancel()
+		if err := p.regenerateThumbnails(ctx, filter); err != nil {
+			log.Errorf("Error regenerating thumbnails: %v", err)
+		}
+	}()
+	return nil
+}
+
+// RegenerateThumbnails makes the thumbnails of the files the filter picks again, with as many
+// workers as scans use. Files that fail keep the thumbnails they had.
+func (p *Processor) RegenerateThumbnails(ctx context.Context, filter RegenerateFilter) error {
+	if !p.regenerating.TryLock() {
+		return ErrRegenerationRunning
+	}
+	defer p.regenerating.Unlock()
+	p.regeneration.start()
+	return p.regenerateThumbnails(ctx, filter)
+}
+
+func (p *Processor) regenerateThumbnails(ctx context.Context, filter RegenerateFilter) (err error) {
+	defer func() { p.regeneration.finish(err) }()
+	files, err := p.selectForRegeneration(filter)
+	if err != nil {
+		return err
+	}
+	log.Infof("Regenerating the thumbnails of %d files", len(files))
+	p.regeneration.total.Store(int64(len(files)))
+
+	fileChan := make(chan *kv.File, p.concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range fileChan {
+				if err := p.throttle.Wait(ctx); err != nil {
+					return
+				}
+				if err := p.regenerateThumbnail(file); err != nil {
+					log.Errorf("Error regenerating thumbnail of %s: %v", file.Filename, err)
+					p.regeneration.failed.Add(1)
+				}
+				p.regeneration.processed.Add(1)
+			}
+		}()
+	}
+feed:
+	for _, file := range files {
+		select {
+		case <-ctx.Done():
+			break feed
+		case fileChan <- file:
+		}
+	}
+	close(fileChan)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log.Infof("Regenerated the thumbnails of %d files, %d failed", len(files), p.regeneration.failed.Load())
+	return nil
+}
+
+// selectForRegeneration lists the files the filter picks, Live Photo videos included
+func (p *Processor) selectForRegeneration(filter RegenerateFilter) ([]*kv.File, error) {
+	ids := filter.IDs
+	if len(ids) == 0 {
+		fileIds, err := p.repo.GetAllFileIds()
+		if err != nil {
+			return nil, fmt.Errorf("error fetching file lists: %w", err)
+		}
+		ids = append(slices.Clone(fileIds.Ids), fileIds.MotionFileIds...)
+	}
+	var files []*kv.File
+	for _, id := range ids {
+		file, err := p.repo.GetFileByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching file %d: %w", id, err)
+		}
+		if filter.Type != "" && file.MimeType != filter.Type {
+			continue
+		}
+		if filter.SizeMismatch && !p.thumbnailMismatch(file) {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// thumbnailMismatch tells whether the thumbnails of a file were made with another MaxThumbnailSize
+// or are missing a size
+func (p *Processor) thumbnailMismatch(file *kv.File) bool {
+	width, height, thumbWidth, thumbHeight := thumbnailDimensions(file)
+	expectedWidth, expectedHeight := p.handler.thumbnailSize(width, height)
+	if file.GetAudio() != nil && !file.GetAudio().HasCoverArt {
+		// Waveforms are drawn at MaxThumbnailSize whatever the file
+		expectedWidth, expectedHeight = uint(p.config.MaxThumbnailSize), uint(p.config.MaxThumbnailSize/2)
+	}
+	if uint64(expectedWidth) != thumbWidth || uint64(expectedHeight) != thumbHeight {
+		return true
+	}
+	for _, size := range utils.ThumbnailSizes {
+		if !slices.ContainsFunc(file.Thumbnails, func(t *kv.Thumbnail) bool { return t.Size == size.String() }) {
+			return true
+		}
+	}
+	return false
+}
+
+func thumbnailDimensions(file *kv.File) (width, height, thumbWidth, thumbHeight uint64) {
+	switch media := file.Media.(type) {
+	case *kv.File_Image:
+		return media.Image.Width, media.Image.Height, media.Image.ThumbnailWidth, media.Image.ThumbnailHeight
+	case *kv.File_Video:
+		return media.Video.Width, media.Video.Height, media.Video.ThumbnailWidth, media.Video.ThumbnailHeight
+	case *kv.File_Audio:
+		// The cover art sets the dimensions of the thumbnail of audio files
+		return media.Audio.ThumbnailWidth, media.Audio.ThumbnailHeight, media.Audio.ThumbnailWidth, media.Audio.ThumbnailHeight
+	}
+	return 0, 0, 0, 0
+}
+
+// regenerateThumbnail runs a file through its handler again and keeps only the thumbnails
+func (p *Processor) regenerateThumbnail(file *kv.File) error {
+	filePath := filepath.Join(p.config.FolderPath, file.Filename)
+	media, mediaFile := p.detectMedia(filePath)
+	if media == nil || media.Type().String() != file.MimeType {
+		return fmt.Errorf("%s is no longer a %s file", file.Filename, file.MimeType)
+	}
+	mediaFile.Hash = file.Hash
+	if err := media.Probe(mediaFile); err != nil {
+		return fmt.Errorf("error probing %s %s: %w", media.Type(), filePath, err)
+	}
+	if err := media.Thumbnail(mediaFile); err != nil {
+		return fmt.Errorf("error creating thumbnail of %s %s: %w", media.Type(), filePath, err)
+	}
+	setBlurHash(mediaFile)
+	blobs, thumbnails, err := p.handler.thumbnailVariants(mediaFile)
+	if err != nil {
+		return fmt.Errorf("error creating thumbnail sizes of %s %s: %w", media.Type(), filePath, err)
+	}
+	file.SetThumbnailSize(mediaFile.ThumbnailWidth, mediaFile.ThumbnailHeight)
+	file.SetBlurHash(mediaFile.BlurHash)
+	file.Thumbnails = thumbnails
+	return p.repo.ReplaceThumbnails(file, blobs)
+}
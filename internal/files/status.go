@@ -0,0 +1,132 @@
+package files
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ScanPhase string
+
+const (
+	PhaseIdle ScanPhase = "idle"
+	// PhaseScanning lists the library and processes files as they are found
+	PhaseScanning ScanPhase = "scanning"
+	// PhaseCleanup removes the records of files that are gone
+	PhaseCleanup ScanPhase = "cleanup"
+)
+
+// ErrScanRunning is returned when a scan is requested while one is in progress
+var ErrScanRunning = errors.New("a scan is already running")
+
+// ErrOutsideScanWindow is returned when a scan is requested outside of the configured ScanWindow
+var ErrOutsideScanWindow = errors.New("scans are not allowed at this time")
+
+// ScanStatus is a snapshot of the progress of the current or last scan
+type ScanStatus struct {
+	Phase      ScanPhase `json:"phase"`
+	Discovered int64     `json:"discovered"`
+	Processed  int64     `json:"processed"`
+	New        int64     `json:"new"`
+	Updated    int64     `json:"updated"`
+	Deleted    int64     `json:"deleted"`
+	Failed     int64     `json:"failed"`
+	// ETASeconds is only known once every file has been discovered
+	ETASeconds    *int64     `json:"eta_seconds"`
+	StartedAt     *time.Time `json:"started_at"`
+	LastCompleted *time.Time `json:"last_completed"`
+	LastError     string     `json:"last_error,omitempty"`
+	// Paused tells why a running scan is waiting, one of the throttle reasons
+	Paused string `json:"paused,omitempty"`
+}
+
+// scanStatus tracks a scan, counters are updated by the workers without locking
+type scanStatus struct {
+	discovered atomic.Int64
+	processed  atomic.Int64
+	new        atomic.Int64
+	updated    atomic.Int64
+	deleted    atomic.Int64
+	failed     atomic.Int64
+
+	mu            sync.Mutex
+	phase         ScanPhase
+	discoveryDone bool
+	startedAt     time.Time
+	lastCompleted time.Time
+	lastError     string
+}
+
+func (s *scanStatus) start() {
+	s.discovered.Store(0)
+	s.processed.Store(0)
+	s.new.Store(0)
+	s.updated.Store(0)
+	s.deleted.Store(0)
+	s.failed.Store(0)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase = PhaseScanning
+	s.discoveryDone = false
+	s.startedAt = time.Now()
+}
+
+func (s *scanStatus) setPhase(phase ScanPhase) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase = phase
+}
+
+func (s *scanStatus) finishDiscovery() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.discoveryDone = true
+}
+
+// finish goes back to idle, a scan only counts as completed when it ran to the end
+func (s *scanStatus) finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase = PhaseIdle
+	if err != nil {
+		s.lastError = err.Error()
+		return
+	}
+	s.lastError = ""
+	s.lastCompleted = time.Now()
+}
+
+func (s *scanStatus) snapshot() ScanStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := ScanStatus{
+		Phase:      s.phase,
+		Discovered: s.discovered.Load(),
+		Processed:  s.processed.Load(),
+		New:        s.new.Load(),
+		Updated:    s.updated.Load(),
+		Deleted:    s.deleted.Load(),
+		Failed:     s.failed.Load(),
+		LastError:  s.lastError,
+	}
+	if status.Phase == "" {
+		status.Phase = PhaseIdle
+	}
+	if !s.startedAt.IsZero() {
+		startedAt := s.startedAt
+		status.StartedAt = &startedAt
+	}
+	if !s.lastCompleted.IsZero() {
+		lastCompleted := s.lastCompleted
+		status.LastCompleted = &lastCompleted
+	}
+	if s.phase == PhaseScanning && s.discoveryDone && status.Processed > 0 {
+		elapsed := time.Since(s.startedAt)
+		remaining := status.Discovered - status.Processed
+		eta := int64((elapsed / time.Duration(status.Processed) * time.Duration(remaining)).Seconds())
+		status.ETASeconds = &eta
+	}
+	return status
+}
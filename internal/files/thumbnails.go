@@ -0,0 +1,89 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"picshow/internal/kv"
+	"picshow/internal/rendition"
+	"picshow/internal/utils"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// thumbnailFormats are the formats every thumbnail size is stored in, browsers that accept WebP get it as it is smaller
+var thumbnailFormats = []rendition.Format{rendition.WebP, rendition.JPEG}
+
+// thumbnailVariants renders the thumbnail a handler made in every size and format. They are all
+// scaled down from it, the large JPEG is the thumbnail itself.
+func (h *handler) thumbnailVariants(file *MediaFile) ([]kv.ThumbnailBlob, []*kv.Thumbnail, error) {
+	var blobs []kv.ThumbnailBlob
+	var thumbnails []*kv.Thumbnail
+	for _, size := range utils.ThumbnailSizes {
+		width, height := fitWithin(file.ThumbnailWidth, file.ThumbnailHeight, size.Pixels(h.config.MaxThumbnailSize))
+		thumbnail := &kv.Thumbnail{Size: size.String(), Width: width, Height: height}
+		for _, format := range thumbnailFormats {
+			data := file.Thumbnail
+			if size != utils.ThumbnailLarge || format != rendition.JPEG {
+				var err error
+				data, err = h.scaleThumbnail(file, width, height, format)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+			blobs = append(blobs, kv.ThumbnailBlob{Size: size.String(), Format: string(format), Data: data})
+			thumbnail.Formats = append(thumbnail.Formats, string(format))
+		}
+		thumbnails = append(thumbnails, thumbnail)
+	}
+	return blobs, thumbnails, nil
+}
+
+// largeThumbnail is the thumbnail a handler made on its own, as the large JPEG
+func largeThumbnail(file *MediaFile) ([]kv.ThumbnailBlob, []*kv.Thumbnail) {
+	size := utils.ThumbnailLarge.String()
+	format := string(rendition.JPEG)
+	return []kv.ThumbnailBlob{{Size: size, Format: format, Data: file.Thumbnail}},
+		[]*kv.Thumbnail{{Size: size, Width: file.ThumbnailWidth, Height: file.ThumbnailHeight, Formats: []string{format}}}
+}
+
+func (h *handler) scaleThumbnail(file *MediaFile, width, height uint64, format rendition.Format) ([]byte, error) {
+	cmd := h.throttle.Command(
+		"convert",
+		"jpg:-",
+		"-resize", fmt.Sprintf("%dx%d!", width, height),
+		"-quality", strconv.Itoa(thumbnailQuality(format)),
+		string(format)+":-",
+	)
+	cmd.Stdin = bytes.NewReader(file.Thumbnail)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmdKey := fmt.Sprintf("convert_%s_%dx%d.%s", file.Hash, width, height, format)
+	h.processes.Store(cmdKey, cmd)
+	defer h.processes.Delete(cmdKey)
+	if err := cmd.Run(); err != nil {
+		log.WithError(err).Errorf("Error scaling thumbnail of %s to %dx%d %s", file.Path, width, height, format)
+		return nil, fmt.Errorf("error executing ImageMagick convert command: %w", newToolError(err, stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func thumbnailQuality(format rendition.Format) int {
+	if format == rendition.WebP {
+		return 75
+	}
+	return 85
+}
+
+// fitWithin scales width x height down so that its longest side is at most maxSize
+func fitWithin(width, height uint64, maxSize int) (uint64, uint64) {
+	size := uint64(maxSize)
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width > height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
@@ -0,0 +1,179 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"picshow/internal/kv"
+	"picshow/internal/utils"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func init() {
+	registerMediaHandler(func(h *handler) MediaHandler { return &videoHandler{h} })
+}
+
+// videoHandler indexes videos with ffprobe and ffmpeg
+type videoHandler struct {
+	*handler
+}
+
+// videoProbe is what videoHandler reads about a video
+type videoProbe struct {
+	result   *probeResult
+	duration float64
+}
+
+func (h *videoHandler) Type() utils.MimeType {
+	return utils.MimeTypeVideo
+}
+
+func (h *videoHandler) Detect(file *MediaFile) bool {
+	return strings.Contains(file.FullMimeType, "video")
+}
+
+func (h *videoHandler) Probe(file *MediaFile) error {
+	log.Debugf("Processing new video: %s", file.Path)
+	probe, err := h.ffprobe(file.Path)
+	if err != nil {
+		return err
+	}
+
+	// Extract video information
+	if videoStream := probe.videoStream(); videoStream != nil {
+		file.Width = uint64(videoStream.Width)
+		file.Height = uint64(videoStream.Height)
+		// Portrait phone videos are stored sideways with a rotation flag that
+		// ffmpeg applies when decoding, so the thumbnail needs the display dimensions
+		if rotation := videoStream.rotation(); rotation == 90 || rotation == 270 {
+			file.Width, file.Height = file.Height, file.Width
+		}
+	}
+
+	duration, err := strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil {
+		log.WithError(err).Errorf("Error parsing video duration from %s", probe.Format.Duration)
+		return fmt.Errorf("error parsing video duration: %w", err)
+	}
+	file.Probed = &videoProbe{result: probe, duration: duration}
+	return nil
+}
+
+func (h *videoHandler) Thumbnail(file *MediaFile) error {
+	probe := file.Probed.(*videoProbe)
+	filePath := file.Path
+	log.Debugf("Generating thumbnail for video %s", filePath)
+
+	screenshotAt := math.Floor(probe.duration * 0.33)
+	thumbWidth, thumbHeight := h.thumbnailSize(file.Width, file.Height)
+
+	// Create temporary file for the thumbnail
+	thumbnailFile, err := os.CreateTemp("", "video_thumbnail_*.jpg")
+	if err != nil {
+		log.WithError(err).Error("Error creating temporary file for video thumbnail")
+		return fmt.Errorf("error creating temporary file for video thumbnail: %w", err)
+	}
+	h.tempFiles.Store(thumbnailFile.Name(), thumbnailFile.Name())
+	defer os.Remove(thumbnailFile.Name())
+	defer h.tempFiles.Delete(thumbnailFile.Name())
+
+	ffmpegCmd := h.throttle.Command(
+		"ffmpeg",
+		"-ss", fmt.Sprintf("%.2f", screenshotAt),
+		"-t", "0.1",
+		"-i", filePath,
+		"-an",
+		"-vframes", "1",
+		"-vf", fmt.Sprintf("scale=%d:%d:flags=fast_bilinear", thumbWidth, thumbHeight),
+		"-f", "mjpeg",
+		"-q:v", "5",
+		"-y",
+		thumbnailFile.Name(),
+	)
+	var ffmpegStderr bytes.Buffer
+	ffmpegCmd.Stderr = &ffmpegStderr
+	ffmpegCmdKey := fmt.Sprintf("ffmpeg_%s", thumbnailFile.Name())
+	h.processes.Store(ffmpegCmdKey, ffmpegCmd)
+	if err := ffmpegCmd.Run(); err != nil {
+		h.processes.Delete(ffmpegCmdKey)
+		log.WithError(err).Errorf("Error processing video %s with FFmpeg", filePath)
+		return fmt.Errorf("error processing video with FFmpeg: %w", newToolError(err, ffmpegStderr.String()))
+	}
+	h.processes.Delete(ffmpegCmdKey)
+	// Read the generated thumbnail file into memory
+	thumbnailData, err := os.ReadFile(thumbnailFile.Name())
+	if err != nil {
+		log.WithError(err).Errorf("Error reading thumbnail file %s", thumbnailFile.Name())
+		return fmt.Errorf("error reading thumbnail file: %w", err)
+	}
+
+	log.Debugf("Generated thumbnail for %s", filePath)
+	file.Thumbnail = thumbnailData
+	file.ThumbnailWidth = uint64(thumbWidth)
+	file.ThumbnailHeight = uint64(thumbHeight)
+	return nil
+}
+
+func (h *videoHandler) Metadata(file *MediaFile, record *kv.File) error {
+	probe := file.Probed.(*videoProbe)
+	video := &kv.Video{
+		FullMimeType:    file.FullMimeType,
+		Width:           file.Width,
+		Height:          file.Height,
+		ThumbnailWidth:  file.ThumbnailWidth,
+		ThumbnailHeight: file.ThumbnailHeight,
+		Blurhash:        file.BlurHash,
+		Length:          uint64(probe.duration),
+		Bitrate:         probe.result.bitRate(),
+		Container:       probe.result.Format.FormatName,
+	}
+	if videoStream := probe.result.videoStream(); videoStream != nil {
+		video.VideoCodec = videoStream.CodecName
+		video.FrameRate = videoStream.frameRate()
+		video.Rotation = videoStream.rotation()
+		if sideData := videoStream.displayMatrix(); sideData != nil {
+			video.DisplayMatrix = strings.TrimSpace(sideData.DisplayMatrix)
+		}
+	}
+	if audioStream := probe.result.audioStream(); audioStream != nil {
+		video.HasAudio = true
+		video.AudioCodec = audioStream.CodecName
+		video.AudioChannels = uint32(audioStream.Channels)
+	}
+	if creationTime := probe.result.creationTime(); creationTime != nil {
+		video.CreationTime = timestamppb.New(*creationTime)
+	}
+	record.Media = &kv.File_Video{Video: video}
+	return nil
+}
+
+// ProbeCodecs reads the codecs of a video indexed before they were kept, they decide whether it
+// has to be transcoded. The file is updated along with its record, other files are left alone.
+func (p *Processor) ProbeCodecs(file *kv.File) error {
+	video := file.GetVideo()
+	if video == nil || video.HasCodecs() {
+		return nil
+	}
+	filePath := filepath.Join(p.config.FolderPath, file.Filename)
+	probe, err := p.handler.ffprobe(filePath)
+	if err != nil {
+		return fmt.Errorf("error probing video %s: %w", filePath, err)
+	}
+	videoStream := probe.videoStream()
+	if videoStream == nil {
+		return fmt.Errorf("%s has no video stream", file.Filename)
+	}
+	video.VideoCodec = videoStream.CodecName
+	video.AudioCodec = ""
+	if audioStream := probe.audioStream(); audioStream != nil {
+		video.AudioCodec = audioStream.CodecName
+	}
+	video.HasAudio = video.AudioCodec != ""
+	return p.repo.SetVideoCodecs(file, video.VideoCodec, video.AudioCodec)
+}
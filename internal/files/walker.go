@@ -0,0 +1,333 @@
+package files
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// discover lists the files of every scan root and hands them to visit, one at a time so that
+// a slow visit holds the listing back. fd is used when fdCommand is set, otherwise the built-in
+// walker lists files in a stable order and skips those up to after, a path an earlier scan reached.
+// An error is returned when a scan root can't be read, complete is false when a folder below one
+// couldn't be, the files that weren't listed must not be taken for deleted ones.
+func (p *Processor) discover(ctx context.Context, fdCommand, after string, visit func(filePath string) error) (complete bool, err error) {
+	if fdCommand != "" {
+		log.Infof("Using %s command for file discovery", fdCommand)
+		return p.discoverWithFd(ctx, fdCommand, visit)
+	}
+	roots := p.config.ScanRoots()
+	realRoots := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if realRoot, err := filepath.EvalSymlinks(root); err == nil {
+			realRoots = append(realRoots, realRoot)
+		}
+	}
+	afterRoot := -1
+	if after != "" {
+		afterRoot = rootIndex(roots, after)
+	}
+	complete = true
+	for i, root := range roots {
+		if i < afterRoot {
+			log.Debugf("Skipping %s, it was scanned before the restart", root)
+			continue
+		}
+		if found, err := p.checkRoot(root); err != nil || !found {
+			if err != nil {
+				return false, err
+			}
+			continue
+		}
+		w := &walker{
+			ctx:            ctx,
+			root:           root,
+			maxDepth:       p.config.MaxDepth,
+			followSymlinks: p.config.FollowSymlinks,
+			includeHidden:  p.config.IncludeHidden,
+			// Nested roots are walked on their own, with their own depth
+			skipDirs:  slices.DeleteFunc(slices.Clone(roots), func(r string) bool { return r == root }),
+			realRoots: realRoots,
+			visited:   make(map[string]bool),
+			visit:     visit,
+		}
+		if i == afterRoot {
+			rel, _ := filepath.Rel(root, after)
+			w.after = splitPath(rel)
+		}
+		if err := w.walk(root, root, 0); err != nil {
+			return false, err
+		}
+		complete = complete && !w.failed
+	}
+	return complete, nil
+}
+
+// checkRoot makes sure a scan root can be listed, all of its files would look deleted otherwise,
+// like those of an unmounted disk. found is false when the upload folder wasn't made yet.
+func (p *Processor) checkRoot(root string) (found bool, err error) {
+	dir, err := os.Open(root)
+	if errors.Is(err, fs.ErrNotExist) && root != p.config.FolderPath {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error opening scan root %s: %w", root, err)
+	}
+	defer dir.Close()
+	if _, err := dir.Readdirnames(1); err != nil && err != io.EOF {
+		return false, fmt.Errorf("error listing scan root %s: %w", root, err)
+	}
+	return true, nil
+}
+
+// rootIndex finds the root a path was listed under, the deepest one when roots are nested
+func rootIndex(roots []string, filePath string) int {
+	index := -1
+	for i, root := range roots {
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if index < 0 || len(root) > len(roots[index]) {
+			index = i
+		}
+	}
+	return index
+}
+
+// fdCommand returns the fd command to list files with, or an empty string for the built-in walker
+func (p *Processor) fdCommand() string {
+	if !p.config.UseFd {
+		return ""
+	}
+	fdCommand, err := findFdCommand()
+	if err != nil {
+		log.Warnf("%v, falling back to the built-in walker", err)
+		return ""
+	}
+	return fdCommand
+}
+
+// discoverWithFd runs fd over each scan root in turn. fd descends into nested roots too, their
+// files are left to the run over the nested root so that they are listed once, with its depth.
+func (p *Processor) discoverWithFd(ctx context.Context, fdCommand string, visit func(filePath string) error) (complete bool, err error) {
+	roots := p.config.ScanRoots()
+	complete = true
+	for i, root := range roots {
+		if found, err := p.checkRoot(root); err != nil || !found {
+			if err != nil {
+				return false, err
+			}
+			continue
+		}
+		rootComplete, err := p.runFd(ctx, fdCommand, root, func(filePath string) error {
+			if rootIndex(roots, filePath) != i {
+				return nil
+			}
+			return visit(filePath)
+		})
+		if err != nil {
+			return false, err
+		}
+		complete = complete && rootComplete
+	}
+	return complete, nil
+}
+
+// runFd lists the files below root with fd, complete is false when fd couldn't read all of them
+func (p *Processor) runFd(ctx context.Context, fdCommand, root string, visit func(filePath string) error) (complete bool, err error) {
+	args := []string{".", "-t", "f"}
+	if p.config.MaxDepth > 0 {
+		args = append(args, "-d", strconv.Itoa(p.config.MaxDepth))
+	}
+	if p.config.IncludeHidden {
+		args = append(args, "--hidden")
+	}
+	if p.config.FollowSymlinks {
+		args = append(args, "--follow")
+	}
+	args = append(args, root)
+	cmd := exec.CommandContext(ctx, fdCommand, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false, fmt.Errorf("error creating stdout pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return false, fmt.Errorf("error starting %s command: %w", fdCommand, err)
+	}
+
+	complete = true
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if err := visit(scanner.Text()); err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return false, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Errorf("Error reading %s output: %v", fdCommand, err)
+		complete = false
+	}
+
+	// fd exits with an error when some folders couldn't be read, after listing the others
+	if err := cmd.Wait(); err != nil {
+		log.Errorf("%s command finished with error: %v", fdCommand, err)
+		complete = false
+	}
+	return complete, nil
+}
+
+func findFdCommand() (string, error) {
+	possibleCommands := []string{"fd", "fdfind", "fd-find"}
+
+	for _, cmd := range possibleCommands {
+		if _, err := exec.LookPath(cmd); err == nil {
+			return cmd, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find fd command. Please install fd, fdfind, or fd-find")
+}
+
+// walker lists files with filepath.WalkDir, following symlinks itself when asked to
+type walker struct {
+	ctx            context.Context
+	root           string
+	maxDepth       int
+	followSymlinks bool
+	includeHidden  bool
+	skipDirs       []string
+	// realRoots are the resolved scan roots, symlinks into them would list files twice
+	realRoots []string
+	// visited holds the real paths of the folders walked through symlinks to break loops
+	visited map[string]bool
+	// after holds the parts of the path relative to root that a resumed scan starts after
+	after []string
+	visit func(filePath string) error
+	// failed is set when a folder couldn't be read, the walk missed its files
+	failed bool
+}
+
+// walk lists dir, whose files are baseDepth+1 levels below the scan root.
+// Paths are reported under displayDir so that files reached through a symlink keep its name.
+func (w *walker) walk(dir, displayDir string, baseDepth int) error {
+	// WalkDir doesn't descend into a symlink, even when it is the folder it is given
+	if realDir, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = realDir
+	}
+	if w.visited[dir] {
+		log.Warnf("Skipping %s, it was already walked", displayDir)
+		return nil
+	}
+	w.visited[dir] = true
+
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := w.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		rel, relErr := filepath.Rel(dir, path)
+		if relErr != nil {
+			return relErr
+		}
+		displayPath := filepath.Join(displayDir, rel)
+		if err != nil {
+			if path == dir && baseDepth == 0 {
+				return fmt.Errorf("error walking scan root %s: %w", displayPath, err)
+			}
+			log.Warnf("Error walking %s: %v", displayPath, err)
+			// A folder removed during the walk took its files with it
+			if !errors.Is(err, fs.ErrNotExist) {
+				w.failed = true
+			}
+			if d != nil && d.IsDir() && path != dir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path == dir {
+			return nil
+		}
+		if w.after != nil {
+			rootRel, err := filepath.Rel(w.root, displayPath)
+			if err == nil && walkPosition(splitPath(rootRel), w.after) == walkDone {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+
+		depth := baseDepth + strings.Count(rel, string(filepath.Separator)) + 1
+		if !w.includeHidden && strings.HasPrefix(d.Name(), ".") && d.IsDir() {
+			return fs.SkipDir
+		}
+
+		switch {
+		case d.IsDir():
+			if slices.Contains(w.skipDirs, displayPath) || !w.descend(depth) {
+				return fs.SkipDir
+			}
+		case d.Type()&fs.ModeSymlink != 0:
+			if w.followSymlinks {
+				return w.followSymlink(path, displayPath, depth)
+			}
+		case d.Type().IsRegular():
+			return w.visit(displayPath)
+		}
+		return nil
+	})
+}
+
+// descend tells whether the files of a folder at depth are within the depth limit, a limit below 1 means none
+func (w *walker) descend(depth int) bool {
+	return w.maxDepth < 1 || depth < w.maxDepth
+}
+
+func (w *walker) followSymlink(path, displayPath string, depth int) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Warnf("Error following symlink %s: %v", displayPath, err)
+			w.failed = true
+		}
+		return nil
+	}
+	for _, root := range w.realRoots {
+		if rel, err := filepath.Rel(root, target); err == nil && !strings.HasPrefix(rel, "..") {
+			log.Debugf("Skipping symlink %s into the library", displayPath)
+			return nil
+		}
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		log.Warnf("Error following symlink %s: %v", displayPath, err)
+		if !errors.Is(err, fs.ErrNotExist) {
+			w.failed = true
+		}
+		return nil
+	}
+	if info.IsDir() {
+		if slices.Contains(w.skipDirs, displayPath) || !w.descend(depth) {
+			return nil
+		}
+		return w.walk(target, displayPath, depth)
+	}
+	if info.Mode().IsRegular() {
+		return w.visit(displayPath)
+	}
+	return nil
+}
@@ -5,9 +5,9 @@ import React, {
   useMemo,
   useRef,
 } from "react";
-import { FaRegPlayCircle } from "react-icons/fa";
+import { FaMusic, FaRegPlayCircle } from "react-icons/fa";
 import { LuLoader2, LuX } from "react-icons/lu";
-import { BASE_URL } from "@/queries/api";
+import { BASE_URL, downloadFiles } from "@/queries/api";
 import Navbar from "@/Navbar";
 import Lightbox, {
   SlideshowRef,
@@ -29,6 +29,9 @@ import {
 import useAppState from "@/state";
 import { useVirtualizer } from "@tanstack/react-virtual";
 import VideoSlide from "@/VideoSlide";
+import AudioSlide from "@/AudioSlide";
+import PreviewThumbnail, { thumbnailSrcSet } from "@/PreviewThumbnail";
+import { placeholderStyle } from "@/blurhash";
 import ConfirmDialog from "@/ConfirmDeleteDialog";
 import KeepAwake from "@/KeepAwake";
 import { LazyLoadImage } from "react-lazy-load-image-component";
@@ -80,25 +83,59 @@ const FileItem = React.memo(
       >
         <figure className="relative w-full h-full overflow-hidden rounded-lg transform group-hover:shadow transition duration-300 ease-out">
           <div className="absolute w-full h-full object-cover rounded-lg transform group-hover:scale-105 transition duration-300 ease-out">
-            {file.Image && (
-              <LazyLoadImage
-                src={file.Image.ThumbnailBase64}
+            {file.Image && !file.Image.MotionURL && (
+              <PreviewThumbnail
+                thumbnailURL={file.Image.ThumbnailURL}
+                blurHash={file.Image.BlurHash}
+                preview={file.Image.PreviewURL}
                 alt={file.Filename}
-                className="w-full h-full object-cover rounded-lg"
               />
             )}
+            {file.Image?.MotionURL && (
+              <div className="relative w-full h-full">
+                <PreviewThumbnail
+                  thumbnailURL={file.Image.ThumbnailURL}
+                  blurHash={file.Image.BlurHash}
+                  preview={file.Image.MotionURL}
+                  video
+                  hover
+                  alt={file.Filename}
+                />
+                <span className="absolute top-2 right-2 rounded bg-black/50 px-1.5 text-xs font-semibold text-white">
+                  LIVE
+                </span>
+              </div>
+            )}
             {file.Video && (
               <div className="relative w-full h-full">
-                <LazyLoadImage
-                  src={file.Video.ThumbnailBase64}
+                <PreviewThumbnail
+                  thumbnailURL={file.Video.ThumbnailURL}
+                  blurHash={file.Video.BlurHash}
+                  preview={file.Video.PreviewURL}
+                  video
                   alt={file.Filename}
-                  className="w-full h-full object-cover rounded-lg"
                 />
                 <div className="absolute inset-0 flex items-center justify-center">
                   <FaRegPlayCircle className="text-white h-16 w-16 text-4xl opacity-70" />
                 </div>
               </div>
             )}
+            {file.Audio && (
+              <div
+                className="relative w-full h-full rounded-lg"
+                style={placeholderStyle(file.Audio.BlurHash)}
+              >
+                <LazyLoadImage
+                  src={file.Audio.ThumbnailURL}
+                  srcSet={thumbnailSrcSet(file.Audio.ThumbnailURL)}
+                  alt={file.Filename}
+                  className="w-full h-full object-cover rounded-lg"
+                />
+                <div className="absolute bottom-2 right-2">
+                  <FaMusic className="text-white h-6 w-6 opacity-70" />
+                </div>
+              </div>
+            )}
           </div>
         </figure>
         {isSelected && (
@@ -150,6 +187,9 @@ const CustomSlide = ({ slide }: any) => {
   if (slide.type === "video") {
     return <VideoSlide slide={slide} />;
   }
+  if (slide.type === "audio") {
+    return <AudioSlide slide={slide} />;
+  }
 };
 
 export default function App() {
@@ -258,6 +298,12 @@ export default function App() {
     setSelectedFiles,
   ]);
 
+  const handleDownload = useCallback(() => {
+    if (selectedFiles.length > 0) {
+      downloadFiles(selectedFiles.join(","));
+    }
+  }, [selectedFiles]);
+
   const confirmDelete = useCallback(() => {
     deleteFileMutation.mutate(deleteDialogState.itemIds.join(","));
     setDeleteDialogState({ isOpen: false, itemIds: [] });
@@ -322,6 +368,8 @@ export default function App() {
         return file.Image.ThumbnailHeight;
       } else if (file.Video) {
         return file.Video.ThumbnailHeight;
+      } else if (file.Audio) {
+        return file.Audio.ThumbnailHeight;
       } else {
         return 300;
       }
@@ -377,16 +425,28 @@ export default function App() {
             type: "video",
             width: file.Video?.Width,
             height: file.Video?.Height,
-            poster: file.Video?.ThumbnailBase64,
+            poster: file.Video?.ThumbnailURL,
             sources: [
               {
-                src: `${BASE_URL}/video/${file.ID}`,
-                type: file.Video?.FullMimeType,
+                src: file.Video?.PlaybackURL ?? `${BASE_URL}/video/${file.ID}`,
+                type: file.Video?.PlaybackMimeType ?? file.Video?.FullMimeType,
               },
             ],
             id: file.ID,
             hash: file.Hash,
           };
+        } else if (file.MimeType === "audio") {
+          return {
+            type: "audio",
+            width: file.Audio?.ThumbnailWidth,
+            height: file.Audio?.ThumbnailHeight,
+            poster: file.Audio?.ThumbnailURL,
+            src: file.Audio?.PlaybackURL ?? `${BASE_URL}/audio/${file.ID}`,
+            title: file.Audio?.Title || file.Filename,
+            artist: file.Audio?.Artist,
+            id: file.ID,
+            hash: file.Hash,
+          };
         } else {
           return {
             type: "image",
@@ -395,7 +455,7 @@ export default function App() {
             height: file.Image?.Height,
             srcSet: [
               {
-                src: file.Image?.ThumbnailBase64,
+                src: file.Image?.ThumbnailURL,
                 width: file.Image?.ThumbnailWidth,
                 height: file.Image?.ThumbnailHeight,
               },
@@ -467,7 +527,7 @@ export default function App() {
     >
       <KeepAwake isActive={isSlideshowPlaying} />
       <div ref={navbarRef}>
-        <Navbar onDelete={handleDelete} />
+        <Navbar onDelete={handleDelete} onDownload={handleDownload} />
       </div>
       <Lightbox
         open={isOpen}
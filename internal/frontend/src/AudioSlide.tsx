@@ -0,0 +1,48 @@
+import { useEffect, useRef } from "react";
+import { useLightboxState } from "yet-another-react-lightbox";
+
+const AudioSlide = ({ slide }: any) => {
+  const audioRef = useRef<HTMLAudioElement>(null);
+  const { slides, currentIndex } = useLightboxState();
+  const isCurrentSlide = slides[currentIndex] === slide;
+
+  useEffect(() => {
+    const audio = audioRef.current;
+
+    if (isCurrentSlide && audio) {
+      audio.play();
+    } else if (audio) {
+      audio.pause();
+      audio.currentTime = 0;
+    }
+
+    return () => {
+      if (audio) {
+        audio.pause();
+        audio.currentTime = 0;
+      }
+    };
+  }, [isCurrentSlide]);
+
+  return (
+    <div className="flex flex-col items-center justify-center gap-4 h-full w-full">
+      <img
+        src={slide.poster}
+        alt={slide.title}
+        className="max-h-[60%] max-w-full rounded-lg object-contain"
+      />
+      <div className="text-center text-white">
+        <p className="text-lg">{slide.title}</p>
+        {slide.artist && <p className="text-sm opacity-70">{slide.artist}</p>}
+      </div>
+      <audio
+        ref={audioRef}
+        src={slide.src}
+        controls
+        className="w-full max-w-xl"
+      />
+    </div>
+  );
+};
+
+export default AudioSlide;
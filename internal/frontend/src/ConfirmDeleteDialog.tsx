@@ -11,8 +11,8 @@ interface ConfirmDialogProps {
   files: Array<{
     ID: number;
     MimeType: string;
-    Image?: { ThumbnailBase64: string };
-    Video?: { ThumbnailBase64: string };
+    Image?: { ThumbnailURL: string };
+    Video?: { ThumbnailURL: string };
   }>;
 }
 
@@ -47,8 +47,8 @@ const ConfirmDialog: React.FC<ConfirmDialogProps> = ({
                   <img
                     src={
                       file.MimeType === "video"
-                        ? file.Video?.ThumbnailBase64
-                        : file.Image?.ThumbnailBase64
+                        ? file.Video?.ThumbnailURL
+                        : file.Image?.ThumbnailURL
                     }
                     alt={`File ${file.ID}`}
                     className="absolute top-0 left-0 w-full h-full object-cover rounded-md"
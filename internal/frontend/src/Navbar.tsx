@@ -6,6 +6,7 @@ import {
   FaUndo,
   FaChartBar,
   FaTrash,
+  FaDownload,
   FaSortAmountDown,
   FaSortAmountUp,
   FaRegCalendarAlt,
@@ -17,7 +18,13 @@ import {
 import { FaShuffle } from "react-icons/fa6";
 import useAppState from "@/state";
 
-const Navbar = ({ onDelete }: { onDelete: () => void }) => {
+const Navbar = ({
+  onDelete,
+  onDownload,
+}: {
+  onDelete: () => void;
+  onDownload: () => void;
+}) => {
   const [isStatsOpen, setIsStatsOpen] = useState(false);
   const {
     sortDirection,
@@ -94,6 +101,12 @@ const Navbar = ({ onDelete }: { onDelete: () => void }) => {
                     >
                       <Select.ItemText>Image</Select.ItemText>
                     </Select.Item>
+                    <Select.Item
+                      value="audio"
+                      className={`cursor-pointer hover:${isDarkMode ? "bg-gray-700" : "bg-gray-100"} rounded px-2 py-1`}
+                    >
+                      <Select.ItemText>Audio</Select.ItemText>
+                    </Select.Item>
                     <Select.Item
                       value="favorite"
                       className={`cursor-pointer hover:${isDarkMode ? "bg-gray-700" : "bg-gray-100"} rounded px-2 py-1`}
@@ -109,6 +122,29 @@ const Navbar = ({ onDelete }: { onDelete: () => void }) => {
         <div className="flex items-center space-x-4">
           {isSelectionMode ? (
             <>
+              <Tooltip.Provider>
+                <Tooltip.Root>
+                  <Tooltip.Trigger asChild>
+                    <button
+                      onClick={onDownload}
+                      className={`hover:${isDarkMode ? "bg-gray-700" : "bg-gray-200"} p-2 rounded-full`}
+                    >
+                      <FaDownload size={20} />
+                    </button>
+                  </Tooltip.Trigger>
+                  <Tooltip.Portal>
+                    <Tooltip.Content
+                      className={`${isDarkMode ? "bg-gray-700 text-white" : "bg-white text-gray-900"} px-2 py-1 rounded text-sm z-50`}
+                    >
+                      Download Selected
+                      <Tooltip.Arrow
+                        className={`fill-${isDarkMode ? "gray-700" : "white"}`}
+                      />
+                    </Tooltip.Content>
+                  </Tooltip.Portal>
+                </Tooltip.Root>
+              </Tooltip.Provider>
+
               <Tooltip.Provider>
                 <Tooltip.Root>
                   <Tooltip.Trigger asChild>
@@ -0,0 +1,88 @@
+import { useEffect, useRef, useState } from "react";
+import { LazyLoadImage } from "react-lazy-load-image-component";
+import { placeholderStyle } from "@/blurhash";
+
+type PreviewThumbnailProps = {
+  // thumbnailURL serves the thumbnail in sizes, high density screens get the large one
+  thumbnailURL: string;
+  // blurHash is painted until the thumbnail loads
+  blurHash?: string;
+  preview?: string;
+  video?: boolean;
+  // hover plays the preview while the pointer is over the tile instead of while it is on screen
+  hover?: boolean;
+  alt: string;
+};
+
+// thumbnailSrcSet lists the small and large thumbnails by screen density
+export const thumbnailSrcSet = (thumbnailURL: string) =>
+  `${thumbnailURL}?size=small 1x, ${thumbnailURL}?size=large 2x`;
+
+// PreviewThumbnail shows the thumbnail and swaps in the looped preview while the tile is on screen,
+// or while it is hovered
+const PreviewThumbnail = ({
+  thumbnailURL,
+  blurHash,
+  preview,
+  video,
+  hover,
+  alt,
+}: PreviewThumbnailProps) => {
+  const ref = useRef<HTMLDivElement>(null);
+  const [isVisible, setIsVisible] = useState(false);
+  const [failed, setFailed] = useState(false);
+
+  useEffect(() => {
+    const element = ref.current;
+    if (!element || !preview || hover) return;
+    const observer = new IntersectionObserver(
+      ([entry]) => setIsVisible(entry.isIntersecting),
+      { threshold: 0.5 },
+    );
+    observer.observe(element);
+    return () => observer.disconnect();
+  }, [preview, hover]);
+
+  const showPreview = preview && isVisible && !failed;
+
+  return (
+    <div
+      ref={ref}
+      className="w-full h-full rounded-lg"
+      style={placeholderStyle(blurHash)}
+      onMouseEnter={hover ? () => setIsVisible(true) : undefined}
+      onMouseLeave={hover ? () => setIsVisible(false) : undefined}
+    >
+      {showPreview && video && (
+        <video
+          src={preview}
+          poster={thumbnailURL}
+          autoPlay
+          muted
+          loop
+          playsInline
+          onError={() => setFailed(true)}
+          className="w-full h-full object-cover rounded-lg"
+        />
+      )}
+      {showPreview && !video && (
+        <img
+          src={preview}
+          alt={alt}
+          onError={() => setFailed(true)}
+          className="w-full h-full object-cover rounded-lg"
+        />
+      )}
+      {!showPreview && (
+        <LazyLoadImage
+          src={thumbnailURL}
+          srcSet={thumbnailSrcSet(thumbnailURL)}
+          alt={alt}
+          className="w-full h-full object-cover rounded-lg"
+        />
+      )}
+    </div>
+  );
+};
+
+export default PreviewThumbnail;
@@ -1,7 +1,13 @@
 import * as Dialog from "@radix-ui/react-dialog";
 import { useStats } from "@/queries/loaders";
 import useAppState from "@/state";
-import { FaImages, FaVideo, FaFileAlt, FaHeart } from "react-icons/fa";
+import {
+  FaImages,
+  FaVideo,
+  FaMusic,
+  FaFileAlt,
+  FaHeart,
+} from "react-icons/fa";
 
 interface StatsDialogProps {
   isOpen: boolean;
@@ -75,6 +81,11 @@ const StatsDialog = ({ isOpen, onClose }: StatsDialogProps) => {
                 title="Videos"
                 value={stats?.video_count}
               />
+              <StatCard
+                icon={<FaMusic size={24} />}
+                title="Audio"
+                value={stats?.audio_count}
+              />
               <StatCard
                 icon={<FaHeart size={24} />}
                 title="Favorites"
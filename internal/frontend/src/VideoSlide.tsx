@@ -1,5 +1,8 @@
 import { useEffect, useRef } from "react";
 import { useLightboxState } from "yet-another-react-lightbox";
+import type Hls from "hls.js";
+
+const HLS_MIME_TYPE = "application/vnd.apple.mpegurl";
 
 const VideoSlide = ({ slide }: any) => {
   const videoRef = useRef<HTMLVideoElement>(null);
@@ -24,11 +27,33 @@ const VideoSlide = ({ slide }: any) => {
     };
   }, [isCurrentSlide]);
 
+  // Safari plays the HLS renditions of transcoded videos natively, other browsers go through hls.js
+  useEffect(() => {
+    const video = videoRef.current;
+    const { src, type } = slide.sources[0];
+    if (!video) return;
+    if (type !== HLS_MIME_TYPE || video.canPlayType(type)) {
+      video.src = src;
+      return;
+    }
+    let hls: Hls | undefined;
+    let cancelled = false;
+    import("hls.js").then(({ default: Hls }) => {
+      if (cancelled || !Hls.isSupported()) return;
+      hls = new Hls();
+      hls.loadSource(src);
+      hls.attachMedia(video);
+    });
+    return () => {
+      cancelled = true;
+      hls?.destroy();
+    };
+  }, [slide]);
+
   return (
     <div className="flex items-center justify-center h-full w-full">
       <video
         ref={videoRef}
-        src={slide.sources[0].src}
         autoPlay
         loop
         controls
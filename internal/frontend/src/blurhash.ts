@@ -0,0 +1,113 @@
+import type { CSSProperties } from "react";
+
+// The server sends a BlurHash of every thumbnail, decoding one to a tiny image is enough
+// for a placeholder since the browser stretches it smoothly
+const PLACEHOLDER_SIZE = 32;
+
+const DIGITS =
+  "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~";
+
+const decode83 = (value: string) => {
+  let result = 0;
+  for (const char of value) {
+    result = result * 83 + DIGITS.indexOf(char);
+  }
+  return result;
+};
+
+const sRGBToLinear = (value: number) => {
+  const v = value / 255;
+  return v <= 0.04045 ? v / 12.92 : Math.pow((v + 0.055) / 1.055, 2.4);
+};
+
+const linearToSRGB = (value: number) => {
+  const v = Math.max(0, Math.min(1, value));
+  return v <= 0.0031308
+    ? Math.round(v * 12.92 * 255 + 0.5)
+    : Math.round((1.055 * Math.pow(v, 1 / 2.4) - 0.055) * 255 + 0.5);
+};
+
+const signPow = (value: number, exp: number) =>
+  Math.sign(value) * Math.pow(Math.abs(value), exp);
+
+// decodeBlurHash returns the RGBA pixels of a BlurHash, undefined when it is malformed
+const decodeBlurHash = (hash: string, width: number, height: number) => {
+  if (hash.length < 6) return undefined;
+  const sizeFlag = decode83(hash[0]);
+  const numY = Math.floor(sizeFlag / 9) + 1;
+  const numX = (sizeFlag % 9) + 1;
+  if (hash.length !== 4 + 2 * numX * numY) return undefined;
+  const maxValue = (decode83(hash[1]) + 1) / 166;
+
+  const colors: number[][] = [];
+  const dc = decode83(hash.substring(2, 6));
+  colors.push([
+    sRGBToLinear(dc >> 16),
+    sRGBToLinear((dc >> 8) & 255),
+    sRGBToLinear(dc & 255),
+  ]);
+  for (let i = 1; i < numX * numY; i++) {
+    const ac = decode83(hash.substring(4 + i * 2, 6 + i * 2));
+    colors.push([
+      signPow((Math.floor(ac / 361) - 9) / 9, 2) * maxValue,
+      signPow(((Math.floor(ac / 19) % 19) - 9) / 9, 2) * maxValue,
+      signPow(((ac % 19) - 9) / 9, 2) * maxValue,
+    ]);
+  }
+
+  const pixels = new Uint8ClampedArray(width * height * 4);
+  for (let y = 0; y < height; y++) {
+    for (let x = 0; x < width; x++) {
+      let r = 0;
+      let g = 0;
+      let b = 0;
+      for (let j = 0; j < numY; j++) {
+        for (let i = 0; i < numX; i++) {
+          const basis =
+            Math.cos((Math.PI * x * i) / width) *
+            Math.cos((Math.PI * y * j) / height);
+          const color = colors[i + j * numX];
+          r += color[0] * basis;
+          g += color[1] * basis;
+          b += color[2] * basis;
+        }
+      }
+      const offset = 4 * (x + y * width);
+      pixels[offset] = linearToSRGB(r);
+      pixels[offset + 1] = linearToSRGB(g);
+      pixels[offset + 2] = linearToSRGB(b);
+      pixels[offset + 3] = 255;
+    }
+  }
+  return pixels;
+};
+
+// Pages are fetched again as the grid scrolls back, each hash is only drawn once
+const placeholders = new Map<string, string | undefined>();
+
+const blurHashToDataURL = (hash: string) => {
+  if (placeholders.has(hash)) return placeholders.get(hash);
+  let url: string | undefined;
+  const pixels = decodeBlurHash(hash, PLACEHOLDER_SIZE, PLACEHOLDER_SIZE);
+  const canvas = document.createElement("canvas");
+  canvas.width = PLACEHOLDER_SIZE;
+  canvas.height = PLACEHOLDER_SIZE;
+  const context = canvas.getContext("2d");
+  if (pixels && context) {
+    context.putImageData(
+      new ImageData(pixels, PLACEHOLDER_SIZE, PLACEHOLDER_SIZE),
+      0,
+      0,
+    );
+    url = canvas.toDataURL();
+  }
+  placeholders.set(hash, url);
+  return url;
+};
+
+// placeholderStyle paints the BlurHash behind a thumbnail until it loads
+export const placeholderStyle = (hash?: string): CSSProperties | undefined => {
+  const url = hash ? blurHashToDataURL(hash) : undefined;
+  if (!url) return undefined;
+  return { backgroundImage: `url(${url})`, backgroundSize: "cover" };
+};
@@ -46,6 +46,21 @@ export const deleteFile = async (ids: string): Promise<void> => {
   });
 };
 
+// Submitting a form lets the browser stream the zip straight to disk
+export const downloadFiles = (ids: string): void => {
+  const form = document.createElement("form");
+  form.method = "POST";
+  form.action = `${BASE_URL}/download`;
+  const input = document.createElement("input");
+  input.type = "hidden";
+  input.name = "ids";
+  input.value = ids;
+  form.appendChild(input);
+  document.body.appendChild(form);
+  form.submit();
+  form.remove();
+};
+
 export const toggleFavorite = async (id: number): Promise<void> => {
   await api.patch(`/${id}/favorite`, {});
 };
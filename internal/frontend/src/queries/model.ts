@@ -1,6 +1,6 @@
 import * as z from "zod";
 
-export const MimeTypeSchema = z.enum(["image", "video"]);
+export const MimeTypeSchema = z.enum(["image", "video", "audio"]);
 export type MimeType = z.infer<typeof MimeTypeSchema>;
 
 export const ImageSchema = z.object({
@@ -11,11 +11,32 @@ export const ImageSchema = z.object({
   FileID: z.number(),
   ThumbnailWidth: z.number(),
   ThumbnailHeight: z.number(),
-  ThumbnailBase64: z.string(),
+  ThumbnailURL: z.string(),
+  BlurHash: z.string(),
   Length: z.number().optional(),
+  PlaybackURL: z.string().optional(),
+  PlaybackMimeType: z.string().optional(),
+  PreviewURL: z.string().optional(),
+  Motion: z.boolean().optional(),
+  MotionURL: z.string().optional(),
 });
 export type Image = z.infer<typeof ImageSchema>;
 
+export const AudioSchema = z.object({
+  FullMimeType: z.string(),
+  Length: z.number(),
+  ThumbnailWidth: z.number(),
+  ThumbnailHeight: z.number(),
+  ThumbnailURL: z.string(),
+  BlurHash: z.string(),
+  HasCoverArt: z.boolean(),
+  PlaybackURL: z.string(),
+  Title: z.string().optional(),
+  Artist: z.string().optional(),
+  Album: z.string().optional(),
+});
+export type Audio = z.infer<typeof AudioSchema>;
+
 export const PaginationSchema = z.object({
   total_records: z.number(),
   current_page: z.number(),
@@ -34,6 +55,7 @@ export const FileSchema = z.object({
   MimeType: MimeTypeSchema,
   Image: ImageSchema.optional(),
   Video: ImageSchema.optional(),
+  Audio: AudioSchema.optional(),
 });
 export type File = z.infer<typeof FileSchema>;
 
@@ -47,6 +69,7 @@ export const StatsSchema = z.object({
   count: z.number(),
   video_count: z.number(),
   image_count: z.number(),
+  audio_count: z.number(),
   favorite_count: z.number(),
 });
 export type Stats = z.infer<typeof StatsSchema>;
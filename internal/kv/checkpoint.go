@@ -0,0 +1,51 @@
+package kv
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+// SetCheckpoint stores the progress of the running scan, replacing the previous checkpoint
+func (r *Repository) SetCheckpoint(checkpoint *ScanCheckpoint) error {
+	data, err := proto.Marshal(checkpoint)
+	if err != nil {
+		log.Errorf("Failed to marshal scan checkpoint: %v", err)
+		return fmt.Errorf("failed to marshal scan checkpoint: %w", err)
+	}
+	return r.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(checkpointKey), data)
+	})
+}
+
+// GetCheckpoint returns the checkpoint of an interrupted scan, nil when the last scan completed
+func (r *Repository) GetCheckpoint() (*ScanCheckpoint, error) {
+	var checkpoint *ScanCheckpoint
+	err := r.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(checkpointKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		checkpoint = new(ScanCheckpoint)
+		return item.Value(func(v []byte) error {
+			return proto.Unmarshal(v, checkpoint)
+		})
+	})
+	if err != nil {
+		log.Errorf("Failed to get scan checkpoint: %v", err)
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// DeleteCheckpoint forgets the scan checkpoint once a scan completes
+func (r *Repository) DeleteCheckpoint() error {
+	return r.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(checkpointKey))
+	})
+}
@@ -71,6 +71,7 @@ func initializeDB(db *badger.DB) error {
 		Count:         0,
 		ImageCount:    0,
 		VideoCount:    0,
+		AudioCount:    0,
 		FavoriteCount: 0,
 	}
 
@@ -0,0 +1,57 @@
+package kv
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+// AddDuplicate records a copy of an indexed file, replacing any record for the same path
+func (r *Repository) AddDuplicate(duplicate *Duplicate) error {
+	log.Debugf("Adding duplicate: %+v", duplicate)
+	data, err := proto.Marshal(duplicate)
+	if err != nil {
+		log.Errorf("Failed to marshal duplicate: %v", err)
+		return fmt.Errorf("failed to marshal duplicate: %w", err)
+	}
+	return r.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(duplicateKey(duplicate.Path), data)
+	})
+}
+
+// GetDuplicates returns every recorded duplicate, ordered by path
+func (r *Repository) GetDuplicates() ([]*Duplicate, error) {
+	duplicates := make([]*Duplicate, 0)
+	err := r.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(duplicatePrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			duplicate := new(Duplicate)
+			err := it.Item().Value(func(v []byte) error {
+				return proto.Unmarshal(v, duplicate)
+			})
+			if err != nil {
+				log.Errorf("Failed to unmarshal duplicate: %v", err)
+				return err
+			}
+			duplicates = append(duplicates, duplicate)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to get duplicates: %v", err)
+		return nil, err
+	}
+	return duplicates, nil
+}
+
+// DeleteDuplicate forgets the duplicate recorded for a path, if any
+func (r *Repository) DeleteDuplicate(path string) error {
+	return r.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(duplicateKey(path))
+	})
+}
@@ -0,0 +1,84 @@
+package kv
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+// SetFailure stores the failure recorded for a file, replacing the previous one
+func (r *Repository) SetFailure(failure *Failure) error {
+	log.Debugf("Storing failure: %+v", failure)
+	data, err := proto.Marshal(failure)
+	if err != nil {
+		log.Errorf("Failed to marshal failure: %v", err)
+		return fmt.Errorf("failed to marshal failure: %w", err)
+	}
+	return r.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(failureKey(failure.Filename), data)
+	})
+}
+
+// GetFailure returns the failure recorded for a file, nil when it never failed
+func (r *Repository) GetFailure(fileName string) (*Failure, error) {
+	var failure *Failure
+	err := r.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(failureKey(fileName))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		failure = new(Failure)
+		return item.Value(func(v []byte) error {
+			return proto.Unmarshal(v, failure)
+		})
+	})
+	if err != nil {
+		log.Errorf("Failed to get failure for %s: %v", fileName, err)
+		return nil, err
+	}
+	return failure, nil
+}
+
+// GetFailures returns every recorded failure, ordered by file name
+func (r *Repository) GetFailures() ([]*Failure, error) {
+	failures := make([]*Failure, 0)
+	err := r.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(failurePrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			failure := new(Failure)
+			err := it.Item().Value(func(v []byte) error {
+				return proto.Unmarshal(v, failure)
+			})
+			if err != nil {
+				log.Errorf("Failed to unmarshal failure: %v", err)
+				return err
+			}
+			failures = append(failures, failure)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to get failures: %v", err)
+		return nil, err
+	}
+	return failures, nil
+}
+
+// DeleteFailure forgets the failure recorded for a file, if any
+func (r *Repository) DeleteFailure(fileName string) error {
+	failure, err := r.GetFailure(fileName)
+	if err != nil || failure == nil {
+		return err
+	}
+	return r.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(failureKey(fileName))
+	})
+}
@@ -0,0 +1,155 @@
+package kv
+
+import (
+	"fmt"
+	"picshow/internal/utils"
+	"slices"
+
+	"github.com/dgraph-io/badger/v2"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// thumbnailsMigratedKey is set once the thumbnails are out of the file records
+	thumbnailsMigratedKey = "migrated:thumbnails"
+	// Records kept inline thumbnails as JPEG
+	legacyThumbnailFormat = "jpeg"
+	// migrationBatchSize keeps the transactions of a migration within Badger's limits
+	migrationBatchSize = 100
+)
+
+// MigrateThumbnails moves the thumbnails kept inside file records to keys of their own, as the
+// large JPEG thumbnail. It runs once, later calls return right away.
+func (r *Repository) MigrateThumbnails() error {
+	migrated := false
+	err := r.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(thumbnailsMigratedKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		migrated = err == nil
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check the thumbnail migration: %w", err)
+	}
+	if migrated {
+		return nil
+	}
+
+	var ids []uint64
+	err = r.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(filePrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var file File
+			if err := it.Item().Value(func(val []byte) error {
+				return proto.Unmarshal(val, &file)
+			}); err != nil {
+				return err
+			}
+			if len(legacyThumbnail(&file)) > 0 {
+				ids = append(ids, file.Id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list files with inline thumbnails: %w", err)
+	}
+	if len(ids) > 0 {
+		log.Infof("Moving the thumbnails of %d files out of their records", len(ids))
+	}
+
+	for start := 0; start < len(ids); start += migrationBatchSize {
+		batch := ids[start:min(start+migrationBatchSize, len(ids))]
+		err := r.db.Update(func(txn *badger.Txn) error {
+			for _, id := range batch {
+				if err := migrateThumbnail(txn, id); err != nil {
+					return fmt.Errorf("failed to migrate the thumbnail of file %d: %w", id, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range batch {
+			r.clearCacheByFileID(id)
+		}
+	}
+	r.clearCache()
+
+	return r.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(thumbnailsMigratedKey), []byte{1})
+	})
+}
+
+func migrateThumbnail(txn *badger.Txn, id uint64) error {
+	var file File
+	if err := getProto(txn, fileKey(id), &file); err != nil {
+		return err
+	}
+	data := legacyThumbnail(&file)
+	if len(data) == 0 {
+		return nil
+	}
+	large := utils.ThumbnailLarge.String()
+	// Files indexed with thumbnail sizes have the large JPEG already
+	key := thumbnailKey(file.Hash, large, legacyThumbnailFormat)
+	if _, err := txn.Get(key); err == badger.ErrKeyNotFound {
+		if err := txn.Set(key, data); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(file.Thumbnails, func(t *Thumbnail) bool { return t.Size == large }) {
+		width, height := thumbnailSize(&file)
+		file.Thumbnails = append(file.Thumbnails, &Thumbnail{
+			Size:    large,
+			Width:   width,
+			Height:  height,
+			Formats: []string{legacyThumbnailFormat},
+		})
+	}
+	clearLegacyThumbnail(&file)
+	return setProto(txn, fileKey(id), &file)
+}
+
+func legacyThumbnail(file *File) []byte {
+	switch media := file.Media.(type) {
+	case *File_Image:
+		return media.Image.ThumbnailData
+	case *File_Video:
+		return media.Video.ThumbnailData
+	case *File_Audio:
+		return media.Audio.ThumbnailData
+	}
+	return nil
+}
+
+func clearLegacyThumbnail(file *File) {
+	switch media := file.Media.(type) {
+	case *File_Image:
+		media.Image.ThumbnailData = nil
+	case *File_Video:
+		media.Video.ThumbnailData = nil
+	case *File_Audio:
+		media.Audio.ThumbnailData = nil
+	}
+}
+
+func thumbnailSize(file *File) (uint64, uint64) {
+	switch media := file.Media.(type) {
+	case *File_Image:
+		return media.Image.ThumbnailWidth, media.Image.ThumbnailHeight
+	case *File_Video:
+		return media.Video.ThumbnailWidth, media.Video.ThumbnailHeight
+	case *File_Audio:
+		return media.Audio.ThumbnailWidth, media.Audio.ThumbnailHeight
+	}
+	return 0, 0
+}
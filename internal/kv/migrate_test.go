@@ -0,0 +1,90 @@
+package kv
+
+import (
+	"path/filepath"
+	"picshow/internal/cache"
+	"picshow/internal/config"
+	"picshow/internal/utils"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestRepository opens a fresh database
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	cfg := &config.Config{DBPath: filepath.Join(t.TempDir(), "db"), CacheSizeMB: 1}
+	db, err := GetDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := cache.NewCache(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewRepository(db, c, cfg)
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestMigrateThumbnailsOutOfRecords(t *testing.T) {
+	repo := newTestRepository(t)
+	files := []*File{
+		{Filename: "old.jpg", Hash: "old", Media: &File_Image{Image: &Image{
+			ThumbnailWidth: 40, ThumbnailHeight: 30, ThumbnailData: []byte("image thumbnail")}}},
+		{Filename: "old.mp4", Hash: "clip", Media: &File_Video{Video: &Video{ThumbnailData: []byte("video thumbnail")}}},
+	}
+	if err := repo.AddBatch(files); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.MigrateThumbnails(); err != nil {
+		t.Fatal(err)
+	}
+
+	large := utils.ThumbnailLarge.String()
+	thumbnails := map[string]string{"old": "image thumbnail", "clip": "video thumbnail"}
+	for hash, want := range thumbnails {
+		thumbnail, found, err := repo.GetThumbnail(hash, large, "jpeg")
+		if err != nil || !found || string(thumbnail) != want {
+			t.Errorf("thumbnail of %s = %q, found %v, err %v", hash, thumbnail, found, err)
+		}
+	}
+	image, err := repo.GetFileByID(files[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(image.GetImage().GetThumbnailData()) != 0 {
+		t.Error("thumbnail is still in the record")
+	}
+	if len(image.Thumbnails) != 1 || image.Thumbnails[0].Width != 40 || image.Thumbnails[0].Height != 30 {
+		t.Errorf("thumbnails = %v, want the large one at 40x30", image.Thumbnails)
+	}
+
+	// The migration runs once, a second call leaves records and thumbnails as they are
+	if err := repo.SetThumbnails("old", []ThumbnailBlob{{Size: large, Format: "jpeg", Data: []byte("regenerated")}}); err != nil {
+		t.Fatal(err)
+	}
+	late := &File{Filename: "late.jpg", Hash: "late", Media: &File_Image{Image: &Image{ThumbnailData: []byte("late thumbnail")}}}
+	if err := repo.AddFile(late); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.MigrateThumbnails(); err != nil {
+		t.Fatal(err)
+	}
+	if record, err := repo.GetFileByID(late.Id); err != nil || string(record.GetImage().GetThumbnailData()) != "late thumbnail" {
+		t.Errorf("record stored after the migration was migrated again (err: %v)", err)
+	}
+	if _, found, err := repo.GetThumbnail("late", large, "jpeg"); err != nil || found {
+		t.Errorf("thumbnail stored after the migration was moved, found %v, err %v", found, err)
+	}
+	again, err := repo.GetFileByID(files[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(again, image) {
+		t.Errorf("record changed to %v, was %v", again, image)
+	}
+	if thumbnail, _, err := repo.GetThumbnail("old", large, "jpeg"); err != nil || string(thumbnail) != "regenerated" {
+		t.Errorf("thumbnail = %q, err %v, want the regenerated one", thumbnail, err)
+	}
+}
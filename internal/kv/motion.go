@@ -0,0 +1,147 @@
+package kv
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/dgraph-io/badger/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// LinkMotion makes a video the motion of a Live Photo still. The video leaves the listings,
+// the counts and the favorites, it is only reached through the still from then on.
+func (r *Repository) LinkMotion(stillID, videoID uint64) error {
+	log.Debugf("Linking video %d as the motion of %d", videoID, stillID)
+	r.clearCacheByFileID(stillID)
+	r.clearCacheByFileID(videoID)
+	defer r.clearCache()
+	return r.db.Update(func(txn *badger.Txn) error {
+		var still, video File
+		if err := getProto(txn, fileKey(stillID), &still); err != nil {
+			return fmt.Errorf("failed to get file %d: %w", stillID, err)
+		}
+		if err := getProto(txn, fileKey(videoID), &video); err != nil {
+			return fmt.Errorf("failed to get file %d: %w", videoID, err)
+		}
+		if still.GetImage() == nil || video.GetVideo() == nil {
+			return fmt.Errorf("file %d is not a still or file %d is not a video", stillID, videoID)
+		}
+		if video.MotionOf != nil {
+			if *video.MotionOf == stillID {
+				return nil
+			}
+			return fmt.Errorf("video %d is already the motion of %d", videoID, *video.MotionOf)
+		}
+
+		var stats Stats
+		if err := getProto(txn, []byte(statsKey), &stats); err != nil {
+			return fmt.Errorf("failed to get stats: %w", err)
+		}
+		var fileIds FileList
+		if err := getProto(txn, []byte(allFilesKey), &fileIds); err != nil {
+			return fmt.Errorf("failed to get all file IDs: %w", err)
+		}
+
+		still.GetImage().Motion = true
+		still.GetImage().MotionVideoId = &videoID
+		video.MotionOf = &stillID
+		isVideo := func(id uint64) bool { return id == videoID }
+		fileIds.Ids = slices.DeleteFunc(fileIds.Ids, isVideo)
+		fileIds.VideoFileIds = slices.DeleteFunc(fileIds.VideoFileIds, isVideo)
+		if slices.Contains(fileIds.FavoriteFileIds, videoID) {
+			fileIds.FavoriteFileIds = slices.DeleteFunc(fileIds.FavoriteFileIds, isVideo)
+			stats.FavoriteCount--
+		}
+		fileIds.MotionFileIds = append(fileIds.MotionFileIds, videoID)
+		stats.Count--
+		stats.VideoCount--
+
+		if err := setProto(txn, fileKey(stillID), &still); err != nil {
+			return fmt.Errorf("failed to update file %d: %w", stillID, err)
+		}
+		if err := setProto(txn, fileKey(videoID), &video); err != nil {
+			return fmt.Errorf("failed to update file %d: %w", videoID, err)
+		}
+		if err := setProto(txn, []byte(statsKey), &stats); err != nil {
+			return fmt.Errorf("failed to update stats: %w", err)
+		}
+		if err := setProto(txn, []byte(allFilesKey), &fileIds); err != nil {
+			return fmt.Errorf("failed to update fileIds: %w", err)
+		}
+		return nil
+	})
+}
+
+// detachMotion undoes the link of a deleted file: a still that loses its video stops being a
+// Live Photo, a video that loses its still is listed and counted again
+func (r *Repository) detachMotion(file *File) error {
+	motionVideoID := file.GetImage().PairedVideo()
+	if file.MotionOf == nil && motionVideoID == nil {
+		return nil
+	}
+	defer r.clearCache()
+	return r.db.Update(func(txn *badger.Txn) error {
+		if file.MotionOf != nil {
+			stillID := *file.MotionOf
+			r.clearCacheByFileID(stillID)
+			var still File
+			err := getProto(txn, fileKey(stillID), &still)
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get file %d: %w", stillID, err)
+			}
+			if videoID := still.GetImage().PairedVideo(); videoID == nil || *videoID != file.Id {
+				return nil
+			}
+			still.GetImage().MotionVideoId = nil
+			// Motion photos keep the video embedded in them
+			still.GetImage().Motion = still.GetImage().MotionOffset > 0
+			return setProto(txn, fileKey(stillID), &still)
+		}
+
+		videoID := *motionVideoID
+		r.clearCacheByFileID(videoID)
+		var video File
+		err := getProto(txn, fileKey(videoID), &video)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get file %d: %w", videoID, err)
+		}
+		if video.MotionOf == nil || *video.MotionOf != file.Id {
+			return nil
+		}
+		var stats Stats
+		if err := getProto(txn, []byte(statsKey), &stats); err != nil {
+			return fmt.Errorf("failed to get stats: %w", err)
+		}
+		var fileIds FileList
+		if err := getProto(txn, []byte(allFilesKey), &fileIds); err != nil {
+			return fmt.Errorf("failed to get all file IDs: %w", err)
+		}
+		video.MotionOf = nil
+		fileIds.MotionFileIds = slices.DeleteFunc(fileIds.MotionFileIds, func(id uint64) bool { return id == videoID })
+		fileIds.Ids = append(fileIds.Ids, videoID)
+		fileIds.VideoFileIds = append(fileIds.VideoFileIds, videoID)
+		stats.Count++
+		stats.VideoCount++
+		if err := setProto(txn, fileKey(videoID), &video); err != nil {
+			return fmt.Errorf("failed to update file %d: %w", videoID, err)
+		}
+		if err := setProto(txn, []byte(statsKey), &stats); err != nil {
+			return fmt.Errorf("failed to update stats: %w", err)
+		}
+		return setProto(txn, []byte(allFilesKey), &fileIds)
+	})
+}
+
+// PairedVideo is the ID of the video of a Live Photo, nil when the image has none
+func (x *Image) PairedVideo() *uint64 {
+	if x == nil {
+		return nil
+	}
+	return x.MotionVideoId
+}
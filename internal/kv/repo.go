@@ -2,6 +2,7 @@ package kv
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 	"picshow/internal/cache"
@@ -9,7 +10,6 @@ import (
 	"picshow/internal/utils"
 	"slices"
 	"sort"
-	"strconv"
 	"sync"
 
 	"github.com/dgraph-io/badger/v2"
@@ -35,11 +35,15 @@ const (
 
 // Keys
 const (
-	filePrefix    = "file:"
-	fileNameIndex = "fileName:"
-	fileHashIndex = "fileHash:"
-	statsKey      = "stats"
-	allFilesKey   = "allFiles"
+	filePrefix      = "file:"
+	fileNameIndex   = "fileName:"
+	fileHashIndex   = "fileHash:"
+	duplicatePrefix = "duplicate:"
+	failurePrefix   = "failure:"
+	thumbnailPrefix = "thumbnail:"
+	statsKey        = "stats"
+	allFilesKey     = "allFiles"
+	checkpointKey   = "scanCheckpoint"
 )
 
 
@@ -70,62 +74,7 @@ func (r *Repository) Open() error {
 
 func (r *Repository) AddFile(file *File) error {
 	log.Debugf("Adding file: %+v", file)
-	defer r.cache.Delete(string(cache.FilesCacheKey))
-	return r.db.Update(func(txn *badger.Txn) error {
-		seq, err := r.db.GetSequence([]byte("file_id_seq"), 100)
-		if err != nil {
-			log.Errorf("Failed to get sequence: %v", err)
-			return err
-		}
-		defer seq.Release()
-
-		id, err := seq.Next()
-		if err != nil {
-			log.Errorf("Failed to get next sequence: %v", err)
-			return err
-		}
-		file.Id = id
-		// Marshal the file using protobuf
-		fileData, err := proto.Marshal(file)
-		if err != nil {
-			log.Errorf("Failed to marshal file: %v", err)
-			return fmt.Errorf("failed to marshal file: %w", err)
-		}
-
-		// Store the file data
-		err = txn.Set([]byte(fmt.Sprintf("%s%d", filePrefix, file.Id)), fileData)
-		if err != nil {
-			log.Errorf("Failed to store file data: %v", err)
-			return fmt.Errorf("failed to store file data: %w", err)
-		}
-
-		// Store the filename index
-		err = txn.Set([]byte(fmt.Sprintf("%s%s", fileNameIndex, file.Filename)), uint64ToBytes(file.Id))
-		if err != nil {
-			log.Errorf("Failed to store filename index: %v", err)
-			return fmt.Errorf("failed to store filename index: %w", err)
-		}
-
-		// Store the file hash index
-		err = txn.Set([]byte(fmt.Sprintf("%s%s", fileHashIndex, file.Hash)), uint64ToBytes(file.Id))
-		if err != nil {
-			log.Errorf("Failed to store file hash index: %v", err)
-			return fmt.Errorf("failed to store file hash index: %w", err)
-		}
-
-		err = r.updateStatsFromOP(Create, file)
-		if err != nil {
-			log.Errorf("Failed to update stats: %v", err)
-			return fmt.Errorf("failed to update stats: %w", err)
-		}
-		err = r.updateAllFilesFromOP(Create, file)
-		if err != nil {
-			log.Errorf("Failed to update fileIds: %v", err)
-			return fmt.Errorf("failed to update fileIds: %w", err)
-		}
-		log.Debugf("File added successfully: %+v", file)
-		return nil
-	})
+	return r.AddBatch([]*File{file})
 }
 
 func (r *Repository) updateAllFilesFromOP(op OP, file *File) error {
@@ -144,9 +93,17 @@ func (r *Repository) updateAllFilesFromOP(op OP, file *File) error {
 		fileIds.ImageFileIds = slices.DeleteFunc(fileIds.ImageFileIds, func(id uint64) bool {
 			return id == file.Id
 		})
+		fileIds.AudioFileIds = slices.DeleteFunc(fileIds.AudioFileIds, func(id uint64) bool {
+			return id == file.Id
+		})
+		fileIds.MotionFileIds = slices.DeleteFunc(fileIds.MotionFileIds, func(id uint64) bool {
+			return id == file.Id
+		})
 		fileIds.FavoriteFileIds = slices.DeleteFunc(fileIds.FavoriteFileIds, func(id uint64) bool {
 			return id == file.Id
 		})
+	} else if file.MotionOf != nil {
+		fileIds.MotionFileIds = append(fileIds.MotionFileIds, file.Id)
 	} else {
 		fileIds.Ids = append(fileIds.Ids, file.Id)
 		switch file.GetMedia().(type) {
@@ -154,6 +111,8 @@ func (r *Repository) updateAllFilesFromOP(op OP, file *File) error {
 			fileIds.ImageFileIds = append(fileIds.ImageFileIds, file.Id)
 		case *File_Video:
 			fileIds.VideoFileIds = append(fileIds.VideoFileIds, file.Id)
+		case *File_Audio:
+			fileIds.AudioFileIds = append(fileIds.AudioFileIds, file.Id)
 		}
 	}
 
@@ -167,6 +126,10 @@ func (r *Repository) updateAllFilesFromOP(op OP, file *File) error {
 
 func (r *Repository) updateStatsFromOP(op OP, file *File) error {
 	defer r.cache.Delete(string(cache.StatsCacheKey))
+	// Live Photo videos are not counted
+	if file.MotionOf != nil {
+		return nil
+	}
 	stats, err := r.GetStats()
 	if err != nil {
 		return fmt.Errorf("failed to get stats: %w", err)
@@ -178,6 +141,8 @@ func (r *Repository) updateStatsFromOP(op OP, file *File) error {
 			stats.ImageCount--
 		case *File_Video:
 			stats.VideoCount--
+		case *File_Audio:
+			stats.AudioCount--
 		}
 		if favorite, _ := r.IsFileFavorite(file.Id); favorite {
 			stats.FavoriteCount--
@@ -189,6 +154,8 @@ func (r *Repository) updateStatsFromOP(op OP, file *File) error {
 			stats.ImageCount++
 		case *File_Video:
 			stats.VideoCount++
+		case *File_Audio:
+			stats.AudioCount++
 		}
 	} else {
 		return nil
@@ -441,6 +408,8 @@ func (r *Repository) GetFiles(
 				allFileIDs = fileList.ImageFileIds
 			} else if *mimetype == utils.MimeTypeVideo.String() {
 				allFileIDs = fileList.VideoFileIds
+			} else if *mimetype == utils.MimeTypeAudio.String() {
+				allFileIDs = fileList.AudioFileIds
 			} else if *mimetype == "favorite" {
 				allFileIDs = fileList.FavoriteFileIds
 			}
@@ -564,12 +533,7 @@ func (r *Repository) GetFileByHash(hash string) (*File, error) {
 		}
 
 		return item.Value(func(val []byte) error {
-			id, err := strconv.ParseUint(string(val), 10, 64)
-			if err != nil {
-				log.Errorf("Failed to parse file ID: %v", err)
-				return fmt.Errorf("failed to parse file ID: %w", err)
-			}
-			fileID = id
+			fileID = bytesToUint64(val)
 			return nil
 		})
 	})
@@ -588,6 +552,46 @@ func (r *Repository) GetFileByHash(hash string) (*File, error) {
 	return file, nil
 }
 
+// LookupHash returns the ID of the file indexed under a hash, found is false when there is none
+func (r *Repository) LookupHash(hash string) (id uint64, found bool, err error) {
+	err = r.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(fileHashKey(hash))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			log.Errorf("Failed to get file hash: %v", err)
+			return fmt.Errorf("failed to get file hash: %w", err)
+		}
+		found = true
+		return item.Value(func(val []byte) error {
+			id = bytesToUint64(val)
+			return nil
+		})
+	})
+	return id, found, err
+}
+
+// LookupFileName returns the ID of the file indexed under a name, found is false when there is none
+func (r *Repository) LookupFileName(fileName string) (id uint64, found bool, err error) {
+	err = r.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(fileNameKey(fileName))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			log.Errorf("Failed to get file name: %v", err)
+			return fmt.Errorf("failed to get file name: %w", err)
+		}
+		found = true
+		return item.Value(func(val []byte) error {
+			id = bytesToUint64(val)
+			return nil
+		})
+	})
+	return id, found, err
+}
+
 // UpdateStats updates the server stats
 func (r *Repository) UpdateStats(stats *Stats) error {
 	log.Debugf("Updating stats: %+v", stats)
@@ -725,11 +729,70 @@ func (r *Repository) UpdateFile(file *File) error {
 	return nil
 }
 
+// ErrNameTaken is returned by RenameFile when another file is indexed under the new name
+var ErrNameTaken = errors.New("file name is already taken")
+
+// RenameFile points the file record and the fileName index at a new name in one transaction,
+// the ID and everything attached to it stay the same
+func (r *Repository) RenameFile(id uint64, newName string) (*File, error) {
+	log.Debugf("Renaming file %d to %s", id, newName)
+	r.clearCacheByFileID(id)
+	defer r.cache.Delete(string(cache.FilesCacheKey))
+
+	var file File
+	err := r.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(fileKey(id))
+		if err != nil {
+			log.Errorf("Failed to get file: %v", err)
+			return err
+		}
+		err = item.Value(func(v []byte) error {
+			return proto.Unmarshal(v, &file)
+		})
+		if err != nil {
+			log.Errorf("Failed to unmarshal file: %v", err)
+			return err
+		}
+		if file.Filename == newName {
+			return nil
+		}
+
+		if _, err := txn.Get(fileNameKey(newName)); err == nil {
+			return ErrNameTaken
+		} else if err != badger.ErrKeyNotFound {
+			log.Errorf("Failed to get file name: %v", err)
+			return err
+		}
+		if err := txn.Delete(fileNameKey(file.Filename)); err != nil {
+			log.Errorf("Failed to delete file name: %v", err)
+			return err
+		}
+		if err := txn.Set(fileNameKey(newName), uint64ToBytes(id)); err != nil {
+			log.Errorf("Failed to store file name: %v", err)
+			return err
+		}
+
+		file.Filename = newName
+		fileData, err := proto.Marshal(&file)
+		if err != nil {
+			log.Errorf("Failed to marshal file: %v", err)
+			return err
+		}
+		return txn.Set(fileKey(id), fileData)
+	})
+	if err != nil {
+		log.Errorf("Failed to rename file: %v", err)
+		return nil, err
+	}
+	return &file, nil
+}
+
 func (r *Repository) DeleteFile(id uint64) error {
 	log.Debugf("Deleting file with ID: %d", id)
 	r.clearCacheByFileID(id)
 	r.clearCache()
 
+	var file File
 	err := r.db.Update(func(txn *badger.Txn) error {
 		item, err := txn.Get(fileKey(id))
 		if err != nil {
@@ -737,7 +800,6 @@ func (r *Repository) DeleteFile(id uint64) error {
 			return err
 		}
 
-		var file File
 		err = item.Value(func(v []byte) error {
 			return proto.Unmarshal(v, &file)
 		})
@@ -761,6 +823,11 @@ func (r *Repository) DeleteFile(id uint64) error {
 			return err
 		}
 
+		if err := deleteThumbnails(txn, file.Hash); err != nil {
+			log.Errorf("Failed to delete thumbnails: %v", err)
+			return err
+		}
+
 		if err := r.updateStatsFromOP(Delete, &file); err != nil {
 			log.Errorf("Failed to update stats: %v", err)
 			return err
@@ -776,6 +843,10 @@ func (r *Repository) DeleteFile(id uint64) error {
 		log.Errorf("Failed to delete file: %v", err)
 		return err
 	}
+	if err := r.detachMotion(&file); err != nil {
+		log.Errorf("Failed to detach motion of file %d: %v", id, err)
+		return err
+	}
 	return nil
 }
 
@@ -821,6 +892,14 @@ func fileHashKey(hash string) []byte {
 	return []byte(fmt.Sprintf("%s%s", fileHashIndex, hash))
 }
 
+func duplicateKey(path string) []byte {
+	return []byte(fmt.Sprintf("%s%s", duplicatePrefix, path))
+}
+
+func failureKey(fileName string) []byte {
+	return []byte(fmt.Sprintf("%s%s", failurePrefix, fileName))
+}
+
 func (r *Repository) clearCacheByFileID(id uint64) {
 	cacheKey := cache.GenerateFileCacheKey(id)
 	contentCacheKey := cache.GenerateFileContentCacheKey(id)
@@ -834,10 +913,11 @@ func (r *Repository) clearCache() {
 	r.cache.Delete(string(cache.RandomCacheKey))
 }
 
-// AddBatch adds multiple files to the repository in a single transaction
+// AddBatch adds multiple files to the repository in a single transaction.
+// The stats and file lists are read and written once for the whole batch.
 func (r *Repository) AddBatch(files []*File) error {
 	log.Debugf("Adding batch of %d files", len(files))
-	defer r.cache.Delete(string(cache.FilesCacheKey))
+	defer r.clearCache()
 	return r.db.Update(func(txn *badger.Txn) error {
 		seq, err := r.db.GetSequence([]byte("file_id_seq"), 100)
 		if err != nil {
@@ -846,6 +926,17 @@ func (r *Repository) AddBatch(files []*File) error {
 		}
 		defer seq.Release()
 
+		var stats Stats
+		if err := getProto(txn, []byte(statsKey), &stats); err != nil {
+			log.Errorf("Failed to get stats: %v", err)
+			return fmt.Errorf("failed to get stats: %w", err)
+		}
+		var fileIds FileList
+		if err := getProto(txn, []byte(allFilesKey), &fileIds); err != nil {
+			log.Errorf("Failed to get all file IDs: %v", err)
+			return fmt.Errorf("failed to get all file IDs: %w", err)
+		}
+
 		for _, file := range files {
 			id, err := seq.Next()
 			if err != nil {
@@ -882,24 +973,58 @@ func (r *Repository) AddBatch(files []*File) error {
 				return fmt.Errorf("failed to store file hash index: %w", err)
 			}
 
-			err = r.updateStatsFromOP(Create, file)
-			if err != nil {
-				log.Errorf("Failed to update stats: %v", err)
-				return fmt.Errorf("failed to update stats: %w", err)
+			if file.MotionOf != nil {
+				// Live Photo videos are not listed nor counted
+				fileIds.MotionFileIds = append(fileIds.MotionFileIds, file.Id)
+				continue
 			}
-
-			err = r.updateAllFilesFromOP(Create, file)
-			if err != nil {
-				log.Errorf("Failed to update fileIds: %v", err)
-				return fmt.Errorf("failed to update fileIds: %w", err)
+			stats.Count++
+			fileIds.Ids = append(fileIds.Ids, file.Id)
+			switch file.GetMedia().(type) {
+			case *File_Image:
+				stats.ImageCount++
+				fileIds.ImageFileIds = append(fileIds.ImageFileIds, file.Id)
+			case *File_Video:
+				stats.VideoCount++
+				fileIds.VideoFileIds = append(fileIds.VideoFileIds, file.Id)
+			case *File_Audio:
+				stats.AudioCount++
+				fileIds.AudioFileIds = append(fileIds.AudioFileIds, file.Id)
 			}
 		}
 
+		if err := setProto(txn, []byte(statsKey), &stats); err != nil {
+			log.Errorf("Failed to update stats: %v", err)
+			return fmt.Errorf("failed to update stats: %w", err)
+		}
+		if err := setProto(txn, []byte(allFilesKey), &fileIds); err != nil {
+			log.Errorf("Failed to update fileIds: %v", err)
+			return fmt.Errorf("failed to update fileIds: %w", err)
+		}
+
 		log.Debugf("Batch of %d files added successfully", len(files))
 		return nil
 	})
 }
 
+func getProto(txn *badger.Txn, key []byte, m proto.Message) error {
+	item, err := txn.Get(key)
+	if err != nil {
+		return err
+	}
+	return item.Value(func(val []byte) error {
+		return proto.Unmarshal(val, m)
+	})
+}
+
+func setProto(txn *badger.Txn, key []byte, m proto.Message) error {
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return txn.Set(key, data)
+}
+
 // UpdateBatch updates multiple files in the repository in a single transaction
 func (r *Repository) UpdateBatch(files []*File) error {
 	log.Debugf("Updating batch of %d files", len(files))
@@ -0,0 +1,166 @@
+package kv
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// ThumbnailBlob is the thumbnail of a file in one size and format
+type ThumbnailBlob struct {
+	Size   string
+	Format string
+	Data   []byte
+}
+
+// SetThumbnails stores the thumbnails of the file indexed under hash, next to the ones it has already
+func (r *Repository) SetThumbnails(hash string, blobs []ThumbnailBlob) error {
+	if len(blobs) == 0 {
+		return nil
+	}
+	err := r.db.Update(func(txn *badger.Txn) error {
+		for _, blob := range blobs {
+			if err := txn.Set(thumbnailKey(hash, blob.Size, blob.Format), blob.Data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to store thumbnails of %s: %v", hash, err)
+		return fmt.Errorf("failed to store thumbnails: %w", err)
+	}
+	return nil
+}
+
+// GetThumbnail returns the thumbnail of the file indexed under hash, found is false when there is none
+// in that size and format
+func (r *Repository) GetThumbnail(hash, size, format string) (data []byte, found bool, err error) {
+	err = r.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(thumbnailKey(hash, size, format))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		data, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		log.Errorf("Failed to get thumbnail of %s: %v", hash, err)
+		return nil, false, fmt.Errorf("failed to get thumbnail: %w", err)
+	}
+	return data, found, nil
+}
+
+// DeleteThumbnails removes every thumbnail of the file indexed under hash
+func (r *Repository) DeleteThumbnails(hash string) error {
+	return r.db.Update(func(txn *badger.Txn) error {
+		return deleteThumbnails(txn, hash)
+	})
+}
+
+func deleteThumbnails(txn *badger.Txn, hash string) error {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	prefix := []byte(fmt.Sprintf("%s%s:", thumbnailPrefix, hash))
+	var keys [][]byte
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		keys = append(keys, it.Item().KeyCopy(nil))
+	}
+	it.Close()
+	for _, key := range keys {
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func thumbnailKey(hash, size, format string) []byte {
+	return []byte(fmt.Sprintf("%s%s:%s.%s", thumbnailPrefix, hash, size, format))
+}
+
+// ReplaceThumbnails stores the thumbnails of a file that were made again in every size, the ones it
+// had before are removed. Only the thumbnail fields of file are kept, the stored record may have changed
+// since it was read. Nothing is written when the record is gone or no longer has the same content.
+func (r *Repository) ReplaceThumbnails(file *File, blobs []ThumbnailBlob) error {
+	log.Debugf("Replacing thumbnails of %s", file.Filename)
+	r.clearCacheByFileID(file.Id)
+	defer r.clearCache()
+	err := r.db.Update(func(txn *badger.Txn) error {
+		var stored File
+		err := getProto(txn, fileKey(file.Id), &stored)
+		if err == badger.ErrKeyNotFound {
+			log.Debugf("File %s was removed, dropping its new thumbnails", file.Filename)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if stored.Hash != file.Hash {
+			log.Debugf("File %s was replaced, dropping its new thumbnails", file.Filename)
+			return nil
+		}
+		stored.SetThumbnailSize(thumbnailSize(file))
+		stored.SetBlurHash(blurHash(file))
+		stored.Thumbnails = file.Thumbnails
+		if err := setProto(txn, fileKey(stored.Id), &stored); err != nil {
+			return err
+		}
+		if err := deleteThumbnails(txn, stored.Hash); err != nil {
+			return err
+		}
+		for _, blob := range blobs {
+			if err := txn.Set(thumbnailKey(stored.Hash, blob.Size, blob.Format), blob.Data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to replace thumbnails of %s: %v", file.Filename, err)
+		return fmt.Errorf("failed to replace thumbnails: %w", err)
+	}
+	return nil
+}
+
+// SetThumbnailSize records the dimensions of the large thumbnail on the image, video or audio of a file
+func (x *File) SetThumbnailSize(width, height uint64) {
+	switch media := x.Media.(type) {
+	case *File_Image:
+		media.Image.ThumbnailWidth, media.Image.ThumbnailHeight = width, height
+	case *File_Video:
+		media.Video.ThumbnailWidth, media.Video.ThumbnailHeight = width, height
+	case *File_Audio:
+		media.Audio.ThumbnailWidth, media.Audio.ThumbnailHeight = width, height
+	}
+}
+
+// SetBlurHash replaces the placeholder of the image, video or audio of a file
+func (x *File) SetBlurHash(hash string) {
+	switch media := x.Media.(type) {
+	case *File_Image:
+		media.Image.Blurhash = hash
+	case *File_Video:
+		media.Video.Blurhash = hash
+	case *File_Audio:
+		media.Audio.Blurhash = hash
+	}
+}
+
+func blurHash(file *File) string {
+	switch media := file.Media.(type) {
+	case *File_Image:
+		return media.Image.Blurhash
+	case *File_Video:
+		return media.Video.Blurhash
+	case *File_Audio:
+		return media.Audio.Blurhash
+	}
+	return ""
+}
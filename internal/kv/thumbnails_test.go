@@ -0,0 +1,48 @@
+package kv
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReplaceThumbnailsKeepsRecordChanges(t *testing.T) {
+	repo := newTestRepository(t)
+	file := &File{Filename: "old.jpg", Hash: "photo", Media: &File_Image{Image: &Image{Width: 640, Height: 480}}}
+	if err := repo.AddFile(file); err != nil {
+		t.Fatal(err)
+	}
+	// The thumbnail is made from a snapshot, the file is renamed meanwhile
+	snapshot := proto.Clone(file).(*File)
+	if _, err := repo.RenameFile(file.Id, "new.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	snapshot.SetThumbnailSize(40, 30)
+	snapshot.SetBlurHash("blur")
+	if err := repo.ReplaceThumbnails(snapshot, []ThumbnailBlob{{Size: "large", Format: "jpeg", Data: []byte("new")}}); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := repo.GetFileByID(file.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	image := stored.GetImage()
+	if stored.Filename != "new.jpg" || image.GetThumbnailWidth() != 40 || image.GetBlurhash() != "blur" {
+		t.Errorf("stored %+v", stored)
+	}
+	if data, found, err := repo.GetThumbnail("photo", "large", "jpeg"); err != nil || !found || string(data) != "new" {
+		t.Errorf("thumbnail = %q, found %v, err %v", data, found, err)
+	}
+
+	// A record that was removed isn't brought back
+	if err := repo.DeleteFile(file.Id); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ReplaceThumbnails(snapshot, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetFileByID(file.Id); err == nil {
+		t.Error("removed file was stored again")
+	}
+}
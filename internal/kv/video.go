@@ -0,0 +1,62 @@
+package kv
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/dgraph-io/badger/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// Containers and codecs that the major browsers can play without help
+var (
+	playableContainers  = []string{"video/mp4", "video/quicktime", "video/webm"}
+	playableVideoCodecs = []string{"h264", "vp8", "vp9", "av1"}
+	playableAudioCodecs = []string{"", "aac", "mp3", "opus", "vorbis"}
+)
+
+// Playable tells whether a video can be streamed as is or has to be transcoded first. It is worked
+// out from the stored codecs rather than when the video is indexed so that videos indexed earlier
+// get transcoded too. A video whose codec isn't known is transcoded, MP4 and QuickTime files can
+// hold HEVC or ProRes as well as H.264, see HasCodecs.
+func (x *Video) Playable() bool {
+	return slices.Contains(playableContainers, x.GetFullMimeType()) &&
+		slices.Contains(playableVideoCodecs, x.GetVideoCodec()) &&
+		slices.Contains(playableAudioCodecs, x.GetAudioCodec())
+}
+
+// HasCodecs tells whether the codecs of a video are known, they weren't kept for videos indexed
+// before codec details were
+func (x *Video) HasCodecs() bool {
+	return x.GetVideoCodec() != ""
+}
+
+// SetVideoCodecs stores the codecs of a video that was probed again. Nothing is written when the
+// record is gone or no longer has the same content.
+func (r *Repository) SetVideoCodecs(file *File, videoCodec, audioCodec string) error {
+	log.Debugf("Setting codecs of %s to %s/%s", file.Filename, videoCodec, audioCodec)
+	r.clearCacheByFileID(file.Id)
+	defer r.clearCache()
+	err := r.db.Update(func(txn *badger.Txn) error {
+		var stored File
+		err := getProto(txn, fileKey(file.Id), &stored)
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		video := stored.GetVideo()
+		if stored.Hash != file.Hash || video == nil {
+			return nil
+		}
+		video.VideoCodec, video.AudioCodec = videoCodec, audioCodec
+		video.HasAudio = audioCodec != ""
+		return setProto(txn, fileKey(stored.Id), &stored)
+	})
+	if err != nil {
+		log.Errorf("Failed to set codecs of %s: %v", file.Filename, err)
+		return fmt.Errorf("failed to set video codecs: %w", err)
+	}
+	return nil
+}
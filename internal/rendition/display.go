@@ -0,0 +1,148 @@
+package rendition
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"picshow/internal/diskcache"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const DisplayMimeType = "image/jpeg"
+
+// Camera RAW extensions, `file` often reports these as TIFF or plain data
+var rawExtensions = []string{".arw", ".cr2", ".cr3", ".dng", ".nef", ".nrw", ".orf", ".pef", ".raf", ".rw2", ".srw"}
+
+// Formats that some or all browsers can't display
+var convertedMimeTypes = []string{"image/heic", "image/heif", "image/avif"}
+
+// IsRaw tells whether the file is a camera RAW judging by its extension
+func IsRaw(filePath string) bool {
+	return slices.Contains(rawExtensions, strings.ToLower(filepath.Ext(filePath)))
+}
+
+// OriginalFormat names the format the file was recorded in, like "jpeg", "heic" or "cr2"
+func OriginalFormat(filePath, fullMimeType string) string {
+	if IsRaw(filePath) {
+		return strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")
+	}
+	_, subtype, found := strings.Cut(fullMimeType, "/")
+	if !found {
+		return strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")
+	}
+	return subtype
+}
+
+// NeedsDisplay tells whether the original has to be converted before a browser can show it
+func NeedsDisplay(filePath, fullMimeType string) bool {
+	return IsRaw(filePath) || slices.Contains(convertedMimeTypes, fullMimeType)
+}
+
+// Display produces browser-safe JPEG renditions of HEIC, AVIF and RAW originals.
+// Renditions live in a size-bounded disk cache keyed by the file hash and are rebuilt when evicted.
+type Display struct {
+	cache *diskcache.Cache
+	locks keyLocks
+}
+
+func NewDisplay(cache *diskcache.Cache) *Display {
+	return &Display{cache: cache}
+}
+
+// Ensure returns the path of the display rendition of srcPath, creating it if needed
+func (d *Display) Ensure(srcPath, hash string) (string, error) {
+	return d.EnsureWith(srcPath, hash, nil)
+}
+
+// EnsureWith is Ensure with prepare applied to the commands that create the rendition,
+// scans use it to lower their priority
+func (d *Display) EnsureWith(srcPath, hash string, prepare func(*exec.Cmd)) (string, error) {
+	key := hash + ".jpg"
+	defer d.locks.lock(key)()
+
+	renditionPath := d.cache.Path(key)
+	if _, err := os.Stat(renditionPath); err == nil {
+		d.cache.Touch(key)
+		return renditionPath, nil
+	}
+
+	// exiftool picks the file type from the extension so the temporary file keeps it
+	tempPath := d.cache.Path(hash + ".part.jpg")
+	defer os.Remove(tempPath)
+	var err error
+	if IsRaw(srcPath) {
+		err = extractRawPreview(srcPath, tempPath, prepare)
+		if err != nil {
+			log.WithError(err).Debugf("No usable embedded preview in %s, converting it", srcPath)
+			err = convertToJPEG(srcPath, tempPath, prepare)
+		}
+	} else {
+		err = convertToJPEG(srcPath+"[0]", tempPath, prepare)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(tempPath, renditionPath); err != nil {
+		return "", fmt.Errorf("error storing display rendition: %w", err)
+	}
+	log.Debugf("Created display rendition for %s", srcPath)
+
+	if err := d.cache.Add(key); err != nil {
+		log.WithError(err).Error("Error evicting display renditions")
+	}
+	return renditionPath, nil
+}
+
+// extractRawPreview copies the full size JPEG most cameras embed in their RAW files,
+// along with the orientation that only the RAW container records
+func extractRawPreview(srcPath, dstPath string, prepare func(*exec.Cmd)) error {
+	var preview []byte
+	for _, tag := range []string{"-JpgFromRaw", "-PreviewImage"} {
+		cmd := command(prepare, "exiftool", "-b", tag, srcPath)
+		output, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("error executing exiftool: %w", err)
+		}
+		if len(output) > 0 {
+			preview = output
+			break
+		}
+	}
+	if len(preview) == 0 {
+		return fmt.Errorf("no embedded preview in %s", srcPath)
+	}
+	if err := os.WriteFile(dstPath, preview, 0644); err != nil {
+		return fmt.Errorf("error writing embedded preview: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := command(prepare, "exiftool", "-q", "-overwrite_original", "-TagsFromFile", srcPath, "-Orientation", dstPath)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		log.WithError(err).Warnf("Error copying orientation to preview of %s: %s", srcPath, stderr.String())
+	}
+	return nil
+}
+
+func convertToJPEG(srcPath, dstPath string, prepare func(*exec.Cmd)) error {
+	var stderr bytes.Buffer
+	cmd := command(prepare, "convert", srcPath, "-auto-orient", "-quality", "90", "jpeg:"+dstPath)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error executing ImageMagick convert command: %w\nstderr: %s", err, stderr.String())
+	}
+	return nil
+}
+
+func command(prepare func(*exec.Cmd), name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	if prepare != nil {
+		prepare(cmd)
+	}
+	return cmd
+}
@@ -0,0 +1,42 @@
+package rendition
+
+import "sync"
+
+// keyLocks serializes the creation of a rendition so that concurrent requests
+// for the same one wait for a single conversion
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock is removed from keyLocks once nobody holds or waits for it
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks key and returns the function that unlocks it
+func (k *keyLocks) lock(key string) (unlock func()) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyLock)
+	}
+	lock, ok := k.locks[key]
+	if !ok {
+		lock = &keyLock{}
+		k.locks[key] = lock
+	}
+	lock.refs++
+	k.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(k.locks, key)
+		}
+	}
+}
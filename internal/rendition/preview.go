@@ -0,0 +1,112 @@
+package rendition
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"picshow/internal/diskcache"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// AnimationMimeType is what previews of animated images are served as
+	AnimationMimeType = "image/webp"
+	// ClipMimeType is what previews of videos are served as
+	ClipMimeType = "video/mp4"
+)
+
+// ImageMagick only reads the first frame of a PNG unless told it is an APNG
+var animatedPNGMimeTypes = []string{"image/png", "image/apng"}
+
+// Preview produces the small looped previews the grid autoplays: a downscaled WebP of
+// animated images and the first seconds of videos. They live in a size-bounded disk cache
+// keyed by the file hash and are rebuilt when evicted.
+type Preview struct {
+	cache   *diskcache.Cache
+	locks   keyLocks
+	size    int
+	seconds int
+	// slots bounds the conversions running at once, scrolling the grid asks for a page of them
+	slots chan struct{}
+}
+
+// NewPreview makes previews that fit within size pixels, video clips last seconds
+func NewPreview(cache *diskcache.Cache, size, seconds int) *Preview {
+	return &Preview{cache: cache, size: size, seconds: seconds, slots: make(chan struct{}, 2)}
+}
+
+// EnsureAnimation returns the path of the animated WebP preview of srcPath, creating it if needed
+func (p *Preview) EnsureAnimation(srcPath, hash, fullMimeType string) (string, error) {
+	source := srcPath
+	if slices.Contains(animatedPNGMimeTypes, fullMimeType) {
+		source = "apng:" + srcPath
+	}
+	return p.ensure(hash+"_preview.webp", func(dstPath string) *exec.Cmd {
+		return exec.Command(
+			"convert",
+			source,
+			"-coalesce",
+			"-resize", fmt.Sprintf("%dx%d>", p.size, p.size),
+			"-loop", "0",
+			"-quality", "60",
+			"webp:"+dstPath,
+		)
+	})
+}
+
+// EnsureClip returns the path of the MP4 preview of the video at srcPath, creating it if needed
+func (p *Preview) EnsureClip(srcPath, hash string) (string, error) {
+	return p.ensure(hash+"_preview.mp4", func(dstPath string) *exec.Cmd {
+		return exec.Command(
+			"ffmpeg",
+			"-i", srcPath,
+			"-t", fmt.Sprintf("%d", p.seconds),
+			"-an",
+			// H.264 needs even dimensions
+			"-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,scale=trunc(iw/2)*2:trunc(ih/2)*2", p.size, p.size),
+			"-c:v", "libx264",
+			"-preset", "veryfast",
+			"-crf", "30",
+			"-pix_fmt", "yuv420p",
+			"-movflags", "+faststart",
+			"-f", "mp4",
+			"-y",
+			dstPath,
+		)
+	})
+}
+
+func (p *Preview) ensure(key string, newCmd func(dstPath string) *exec.Cmd) (string, error) {
+	defer p.locks.lock(key)()
+
+	previewPath := p.cache.Path(key)
+	if _, err := os.Stat(previewPath); err == nil {
+		p.cache.Touch(key)
+		return previewPath, nil
+	}
+
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+
+	tempPath := previewPath + ".tmp"
+	defer os.Remove(tempPath)
+	var stderr bytes.Buffer
+	cmd := newCmd(tempPath)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		log.WithError(err).Errorf("Error creating preview %s\nstderr: %s", key, stderr.String())
+		return "", fmt.Errorf("error executing %s: %w", cmd.Args[0], err)
+	}
+	if err := os.Rename(tempPath, previewPath); err != nil {
+		return "", fmt.Errorf("error storing preview: %w", err)
+	}
+	log.Debugf("Created preview %s", key)
+
+	if err := p.cache.Add(key); err != nil {
+		log.WithError(err).Error("Error evicting previews")
+	}
+	return previewPath, nil
+}
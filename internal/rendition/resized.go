@@ -0,0 +1,87 @@
+package rendition
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"picshow/internal/diskcache"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	WebP Format = "webp"
+)
+
+func (f Format) MimeType() string {
+	return "image/" + string(f)
+}
+
+// Widths renditions are produced at, requested widths are rounded up to one of them
+// so that a handful of files per image ends up in the cache
+var widths = []int{320, 640, 960, 1280, 1920, 2560, 3840}
+
+// SnapWidth rounds a requested width up to a rendition width, 0 means the original is best
+func SnapWidth(requested int, originalWidth uint64) int {
+	for _, w := range widths {
+		if w >= requested {
+			if uint64(w) >= originalWidth {
+				return 0
+			}
+			return w
+		}
+	}
+	return 0
+}
+
+// Resized produces downscaled renditions of images in a size-bounded disk cache keyed by the file hash
+type Resized struct {
+	cache *diskcache.Cache
+	locks keyLocks
+}
+
+func NewResized(cache *diskcache.Cache) *Resized {
+	return &Resized{cache: cache}
+}
+
+// Ensure returns the path of the rendition of srcPath at the given width and format, creating it if needed
+func (r *Resized) Ensure(srcPath, hash string, width int, format Format) (string, error) {
+	key := fmt.Sprintf("%s_%d.%s", hash, width, format)
+	defer r.locks.lock(key)()
+
+	renditionPath := r.cache.Path(key)
+	if _, err := os.Stat(renditionPath); err == nil {
+		r.cache.Touch(key)
+		return renditionPath, nil
+	}
+
+	tempPath := renditionPath + ".tmp"
+	defer os.Remove(tempPath)
+	var stderr bytes.Buffer
+	cmd := exec.Command(
+		"convert",
+		srcPath+"[0]",
+		"-auto-orient",
+		"-resize", fmt.Sprintf("%dx>", width),
+		"-quality", "82",
+		string(format)+":"+tempPath,
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		log.WithError(err).Errorf("Error resizing %s\nstderr: %s", srcPath, stderr.String())
+		return "", fmt.Errorf("error executing ImageMagick convert command: %w", err)
+	}
+	if err := os.Rename(tempPath, renditionPath); err != nil {
+		return "", fmt.Errorf("error storing resized rendition: %w", err)
+	}
+	log.Debugf("Created %dpx %s rendition for %s", width, format, srcPath)
+
+	if err := r.cache.Add(key); err != nil {
+		log.WithError(err).Error("Error evicting resized renditions")
+	}
+	return renditionPath, nil
+}
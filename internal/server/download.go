@@ -0,0 +1,132 @@
+package server
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"picshow/internal/kv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *Server) downloadFile(e echo.Context) error {
+	id := e.Param("id")
+	fileId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Errorf("Invalid file ID: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid file id"})
+	}
+	file, err := s.repo.GetFileByID(fileId)
+	if err != nil {
+		log.Errorf("Failed to fetch file from repository: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch file"})
+	}
+	log.Debugf("Serving original file for download: %s", file.Filename)
+	e.Response().Header().Set(echo.HeaderContentDisposition, contentDisposition(filepath.Base(file.Filename)))
+	return serveFile(e, filepath.Join(s.config.FolderPath, file.Filename), originalMimeType(file))
+}
+
+// downloadZip streams the selected originals as a zip archive, one file at a time
+func (s *Server) downloadZip(e echo.Context) error {
+	u := new(downloadRequest)
+	if err := e.Bind(u); err != nil {
+		log.Errorf("Failed to parse download request body: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to parse request body"})
+	}
+	files, err := s.repo.GetFilesByIds(u.toIds())
+	if err != nil {
+		log.Errorf("Failed to fetch files from repository: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch files"})
+	}
+	if len(files) == 0 {
+		return e.JSON(http.StatusNotFound, map[string]string{"error": "No files to download"})
+	}
+
+	archiveName := fmt.Sprintf("picshow_%s.zip", time.Now().Format(time.DateOnly))
+	e.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	e.Response().Header().Set(echo.HeaderContentDisposition, contentDisposition(archiveName))
+	e.Response().WriteHeader(http.StatusOK)
+
+	// Headers are sent at this point so errors can only be logged
+	zipWriter := zip.NewWriter(e.Response())
+	usedNames := make(map[string]bool, len(files))
+	for _, file := range files {
+		name := uniqueArchiveName(filepath.Base(file.Filename), usedNames)
+		if err := addToZip(zipWriter, filepath.Join(s.config.FolderPath, file.Filename), name); err != nil {
+			log.Errorf("Failed to add %s to zip: %v", file.Filename, err)
+			return nil
+		}
+		e.Response().Flush()
+	}
+	if err := zipWriter.Close(); err != nil {
+		log.Errorf("Failed to finish zip: %v", err)
+		return nil
+	}
+	log.Infof("Streamed zip of %d files", len(files))
+	return nil
+}
+
+func addToZip(zipWriter *zip.Writer, filePath, name string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	// Photos and videos are already compressed, storing them keeps the CPU free
+	header.Method = zip.Store
+	w, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// uniqueArchiveName appends a counter to names that are already in the archive
+func uniqueArchiveName(name string, used map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 1; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+func originalMimeType(file *kv.File) string {
+	switch media := file.Media.(type) {
+	case *kv.File_Image:
+		return media.Image.FullMimeType
+	case *kv.File_Video:
+		return media.Video.FullMimeType
+	case *kv.File_Audio:
+		return media.Audio.FullMimeType
+	}
+	return echo.MIMEOctetStream
+}
+
+// contentDisposition encodes non-ASCII file names as RFC 2231 asks for
+func contentDisposition(name string) string {
+	if disposition := mime.FormatMediaType("attachment", map[string]string{"filename": name}); disposition != "" {
+		return disposition
+	}
+	return "attachment"
+}
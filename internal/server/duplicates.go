@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// getDuplicates reports each indexed file that has copies, with the copies
+func (s *Server) getDuplicates(e echo.Context) error {
+	duplicates, err := s.repo.GetDuplicates()
+	if err != nil {
+		log.Errorf("Failed to fetch duplicates from repository: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch duplicates"})
+	}
+
+	groups := make([]*DuplicateGroup, 0)
+	byCanonical := make(map[uint64]*DuplicateGroup)
+	for _, duplicate := range duplicates {
+		group, found := byCanonical[duplicate.CanonicalId]
+		if !found {
+			file, err := s.repo.GetFileByID(duplicate.CanonicalId)
+			if err != nil {
+				// The indexed file was deleted since, the next scan drops the record
+				log.Debugf("Skipping duplicate %s of missing file %d", duplicate.Path, duplicate.CanonicalId)
+				continue
+			}
+			group = &DuplicateGroup{File: MapProtoFileToServerFile(file)}
+			byCanonical[duplicate.CanonicalId] = group
+			groups = append(groups, group)
+		}
+		group.Copies = append(group.Copies, MapProtoDuplicateToServerDuplicate(duplicate))
+	}
+	return e.JSON(http.StatusOK, groups)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"picshow/internal/files"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// failureResult is the outcome of retrying or ignoring one failed file
+type failureResult struct {
+	Filename string
+	Error    string `json:",omitempty"`
+}
+
+func (s *Server) getFailures(e echo.Context) error {
+	failures, err := s.repo.GetFailures()
+	if err != nil {
+		log.Errorf("Failed to fetch failures from repository: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch failures"})
+	}
+	response := make([]*Failure, 0, len(failures))
+	for _, failure := range failures {
+		response = append(response, MapProtoFailureToServerFailure(failure))
+	}
+	return e.JSON(http.StatusOK, response)
+}
+
+// retryFailures processes the failed files again right away
+func (s *Server) retryFailures(e echo.Context) error {
+	return s.applyToFailures(e, s.processor.Retry)
+}
+
+// ignoreFailures makes scans skip the failed files until they are retried
+func (s *Server) ignoreFailures(e echo.Context) error {
+	return s.applyToFailures(e, func(filename string) error {
+		return files.IgnoreFailure(s.repo, filename)
+	})
+}
+
+func (s *Server) applyToFailures(e echo.Context, apply func(filename string) error) error {
+	u := new(failuresRequest)
+	if err := e.Bind(u); err != nil {
+		log.Errorf("Failed to parse failures request body: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to parse request body"})
+	}
+	results := make([]failureResult, 0, len(u.Filenames))
+	for _, filename := range u.Filenames {
+		result := failureResult{Filename: filename}
+		if err := apply(filename); err != nil {
+			log.Errorf("Failed to update failure of %s: %v", filename, err)
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return e.JSON(http.StatusOK, results)
+}
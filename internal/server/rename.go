@@ -0,0 +1,174 @@
+package server
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"picshow/internal/kv"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+var errDestinationExists = errors.New("destination already exists")
+
+// moveResult is the outcome of moving one file, files that fail don't stop the others
+type moveResult struct {
+	ID    uint64
+	Name  string
+	File  *File  `json:",omitempty"`
+	Error string `json:",omitempty"`
+}
+
+// getFolders lists the folders files can be moved to, relative to the library folder
+func (s *Server) getFolders(e echo.Context) error {
+	folders := make([]string, 0)
+	for _, root := range s.config.ScanRoots() {
+		folders = append(folders, s.libraryFolder(root))
+	}
+	return e.JSON(http.StatusOK, folders)
+}
+
+func (s *Server) renameFile(e echo.Context) error {
+	fileId, err := strconv.ParseUint(e.Param("id"), 10, 64)
+	if err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid file id"})
+	}
+	u := new(renameRequest)
+	if err := e.Bind(u); err != nil {
+		log.Errorf("Failed to parse rename request body: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to parse request body"})
+	}
+	if !validFileName(u.Name) {
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid file name"})
+	}
+	file, err := s.repo.GetFileByID(fileId)
+	if err != nil {
+		log.Errorf("Failed to fetch file from repository: %v", err)
+		return e.JSON(http.StatusNotFound, map[string]string{"error": "File not found"})
+	}
+
+	renamed, err := s.moveFile(file, filepath.Join(filepath.Dir(file.Filename), u.Name))
+	if err != nil {
+		status, message := moveError(file, err)
+		return e.JSON(status, map[string]string{"error": message})
+	}
+	return e.JSON(http.StatusOK, MapProtoFileToServerFile(renamed))
+}
+
+// moveFiles moves the selected files to another folder and reports how each one went,
+// with the status of a failure only when none of them moved
+func (s *Server) moveFiles(e echo.Context) error {
+	u := new(moveRequest)
+	if err := e.Bind(u); err != nil {
+		log.Errorf("Failed to parse move request body: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to parse request body"})
+	}
+	folder, ok := s.scannedFolder(u.Folder)
+	if !ok {
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Files can only be moved to the library folder or the upload folder"})
+	}
+	files, err := s.repo.GetFilesByIds(u.toIds())
+	if err != nil {
+		log.Errorf("Failed to fetch files from repository: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch files"})
+	}
+
+	results := make([]moveResult, 0, len(files))
+	moved := 0
+	status := http.StatusOK
+	for _, file := range files {
+		result := moveResult{ID: file.Id, Name: file.Filename}
+		newFile, err := s.moveFile(file, filepath.Join(folder, filepath.Base(file.Filename)))
+		if err != nil {
+			status, result.Error = moveError(file, err)
+		} else {
+			result.File = MapProtoFileToServerFile(newFile)
+			moved++
+		}
+		results = append(results, result)
+	}
+	if moved > 0 || len(files) == 0 {
+		status = http.StatusOK
+	}
+	return e.JSON(status, results)
+}
+
+// moveFile renames the file on disk then in the index, putting it back on disk if the index refuses
+func (s *Server) moveFile(file *kv.File, newName string) (*kv.File, error) {
+	newName = filepath.Clean(newName)
+	if newName == file.Filename {
+		return file, nil
+	}
+	oldPath := filepath.Join(s.config.FolderPath, file.Filename)
+	newPath := filepath.Join(s.config.FolderPath, newName)
+	if err := renameNoReplace(oldPath, newPath); err != nil {
+		return nil, err
+	}
+	renamed, err := s.repo.RenameFile(file.Id, newName)
+	if err != nil {
+		if revertErr := renameNoReplace(newPath, oldPath); revertErr != nil {
+			log.Errorf("Failed to move %s back to %s: %v", newPath, oldPath, revertErr)
+		}
+		return nil, err
+	}
+	log.Infof("Moved %s to %s", file.Filename, newName)
+	return renamed, nil
+}
+
+// moveError is the status and the message a failed move is reported with
+func moveError(file *kv.File, err error) (int, string) {
+	if errors.Is(err, errDestinationExists) || errors.Is(err, kv.ErrNameTaken) {
+		return http.StatusConflict, "A file with that name already exists"
+	}
+	log.Errorf("Failed to move file %s: %v", file.Filename, err)
+	return http.StatusInternalServerError, "Failed to move file"
+}
+
+// scannedFolder resolves a folder relative to the library, files anywhere else wouldn't be found by scans
+func (s *Server) scannedFolder(folder string) (string, bool) {
+	for _, root := range s.config.ScanRoots() {
+		relative := s.libraryFolder(root)
+		if filepath.Clean(folder) == relative || (folder == "" && relative == ".") {
+			return relative, true
+		}
+	}
+	return "", false
+}
+
+func (s *Server) libraryFolder(root string) string {
+	relative, err := filepath.Rel(s.config.FolderPath, root)
+	if err != nil {
+		return "."
+	}
+	return relative
+}
+
+// renameNoReplace moves oldPath to newPath without overwriting what is there.
+// Linking fails when newPath exists, which a rename would silently replace.
+func renameNoReplace(oldPath, newPath string) error {
+	err := os.Link(oldPath, newPath)
+	if err == nil {
+		return os.Remove(oldPath)
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return errDestinationExists
+	}
+	// Some filesystems don't support hard links
+	if _, statErr := os.Lstat(newPath); !errors.Is(statErr, fs.ErrNotExist) {
+		return errDestinationExists
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// validFileName accepts plain names, hidden files would be skipped by scans
+func validFileName(name string) bool {
+	return name != "" &&
+		!strings.HasPrefix(name, ".") &&
+		!strings.ContainsAny(name, "/\\") &&
+		!strings.ContainsFunc(name, func(r rune) bool { return r < 0x20 || r == 0x7f })
+}
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"picshow/internal/rendition"
+	"picshow/internal/utils"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -43,10 +46,54 @@ func (fq *fileQuery) String() string {
 	return "page: " + strconv.Itoa(*fq.Page) + ", page_size: " + strconv.Itoa(*fq.PageSize) + ", order: " + *fq.Order + ", direction: " + *fq.OrderDir
 }
 
+type imageQuery struct {
+	Orient string `query:"orient"`
+	Width  int    `query:"w"`
+	Format string `query:"format"`
+}
+
+// format picks the rendition format, WebP when the client says it accepts it
+func (iq *imageQuery) format(accept string) rendition.Format {
+	return negotiateFormat(iq.Format, accept)
+}
+
+type thumbnailQuery struct {
+	Size   string `query:"size"`
+	Format string `query:"format"`
+}
+
+// size picks the thumbnail size, the large one when none is asked for
+func (tq *thumbnailQuery) size() (utils.ThumbnailSize, bool) {
+	if tq.Size == "" {
+		return utils.ThumbnailLarge, true
+	}
+	size := utils.ThumbnailSize(tq.Size)
+	return size, slices.Contains(utils.ThumbnailSizes, size)
+}
+
+func (tq *thumbnailQuery) format(accept string) rendition.Format {
+	return negotiateFormat(tq.Format, accept)
+}
+
+// negotiateFormat honours the requested format, else picks WebP when the Accept header allows it
+func negotiateFormat(requested, accept string) rendition.Format {
+	switch rendition.Format(requested) {
+	case rendition.JPEG, rendition.WebP:
+		return rendition.Format(requested)
+	}
+	if strings.Contains(accept, rendition.WebP.MimeType()) {
+		return rendition.WebP
+	}
+	return rendition.JPEG
+}
+
 type deleteRequest struct {
-	IDs string `json:"ids"`
+	IDs string `json:"ids" form:"ids"`
 }
 
+// downloadRequest takes the same comma-separated ids as deleteRequest
+type downloadRequest = deleteRequest
+
 func (d deleteRequest) toIds() []uint64 {
 	idList := strings.Split(d.IDs, ",")
 	ids := make([]uint64, len(idList))
@@ -55,3 +102,21 @@ func (d deleteRequest) toIds() []uint64 {
 	}
 	return ids
 }
+
+type renameRequest struct {
+	Name string `json:"name"`
+}
+
+type moveRequest struct {
+	IDs string `json:"ids"`
+	// Folder is relative to the library folder, empty for the library folder itself
+	Folder string `json:"folder"`
+}
+
+func (m moveRequest) toIds() []uint64 {
+	return deleteRequest{IDs: m.IDs}.toIds()
+}
+
+type failuresRequest struct {
+	Filenames []string `json:"filenames"`
+}
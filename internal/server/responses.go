@@ -1,7 +1,8 @@
 package server
 
 import (
-	"picshow/internal/utils"
+	"fmt"
+	"picshow/internal/transcode"
 	"time"
 
 	pb "picshow/internal/kv"
@@ -17,6 +18,7 @@ type File struct {
 	LastModified int64
 	Image        *Image `json:",omitempty"`
 	Video        *Video `json:",omitempty"`
+	Audio        *Audio `json:",omitempty"`
 }
 
 type Image struct {
@@ -26,7 +28,15 @@ type Image struct {
 	Height          uint64
 	ThumbnailWidth  uint64
 	ThumbnailHeight uint64
-	ThumbnailBase64 string
+	BlurHash        string
+	ThumbnailURL    string
+	Orientation     uint32
+	OriginalFormat  string
+	// PreviewURL points to a looped WebP preview, only animated images have one
+	PreviewURL string `json:",omitempty"`
+	// Motion is set on Live Photos and motion photos, MotionURL plays their video
+	Motion    bool
+	MotionURL string `json:",omitempty"`
 }
 
 type Video struct {
@@ -38,7 +48,46 @@ type Video struct {
 	FileID          uint64
 	ThumbnailWidth  uint64
 	ThumbnailHeight uint64
-	ThumbnailBase64 string
+	BlurHash        string
+	ThumbnailURL    string
+	// PlaybackURL points to the original file or to an HLS rendition when the browser can't play it
+	PlaybackURL      string
+	PlaybackMimeType string
+	VideoCodec       string
+	AudioCodec       string
+	Bitrate          uint64
+	FrameRate        float64
+	Rotation         int32
+	DisplayMatrix    string
+	HasAudio         bool
+	AudioChannels    uint32
+	Container        string
+	CreationTime     *time.Time `json:",omitempty"`
+	// PreviewURL points to a short muted MP4 the grid autoplays
+	PreviewURL string
+}
+
+type Audio struct {
+	FullMimeType    string
+	Length          uint64
+	ThumbnailWidth  uint64
+	ThumbnailHeight uint64
+	BlurHash        string
+	ThumbnailURL    string
+	// HasCoverArt tells whether the thumbnail is the album cover or a waveform
+	HasCoverArt  bool
+	PlaybackURL  string
+	Codec        string
+	Bitrate      uint64
+	SampleRate   uint32
+	Channels     uint32
+	Container    string
+	Title        string
+	Artist       string
+	Album        string
+	Genre        string
+	Date         string
+	CreationTime *time.Time `json:",omitempty"`
 }
 
 func MapProtoFileToServerFile(protoFile *pb.File) *File {
@@ -60,7 +109,17 @@ func MapProtoFileToServerFile(protoFile *pb.File) *File {
 			Height:          media.Image.Height,
 			ThumbnailWidth:  media.Image.ThumbnailWidth,
 			ThumbnailHeight: media.Image.ThumbnailHeight,
-			ThumbnailBase64: utils.ThumbBytesToBase64(media.Image.ThumbnailData),
+			BlurHash:        media.Image.Blurhash,
+			ThumbnailURL:    fmt.Sprintf("/api/thumbnail/%d", protoFile.Id),
+			Orientation:     media.Image.Orientation,
+			OriginalFormat:  media.Image.OriginalFormat,
+		}
+		if media.Image.Animated {
+			serverFile.Image.PreviewURL = fmt.Sprintf("/api/preview/%d", protoFile.Id)
+		}
+		if media.Image.Motion {
+			serverFile.Image.Motion = true
+			serverFile.Image.MotionURL = fmt.Sprintf("/api/motion/%d", protoFile.Id)
 		}
 	case *pb.File_Video:
 		serverFile.Video = &Video{
@@ -70,7 +129,54 @@ func MapProtoFileToServerFile(protoFile *pb.File) *File {
 			Length:          media.Video.Length,
 			ThumbnailWidth:  media.Video.ThumbnailWidth,
 			ThumbnailHeight: media.Video.ThumbnailHeight,
-			ThumbnailBase64: utils.ThumbBytesToBase64(media.Video.ThumbnailData),
+			BlurHash:        media.Video.Blurhash,
+			ThumbnailURL:    fmt.Sprintf("/api/thumbnail/%d", protoFile.Id),
+			VideoCodec:      media.Video.VideoCodec,
+			AudioCodec:      media.Video.AudioCodec,
+			Bitrate:         media.Video.Bitrate,
+			FrameRate:       media.Video.FrameRate,
+			Rotation:        media.Video.Rotation,
+			DisplayMatrix:   media.Video.DisplayMatrix,
+			HasAudio:        media.Video.HasAudio,
+			AudioChannels:   media.Video.AudioChannels,
+			Container:       media.Video.Container,
+			PreviewURL:      fmt.Sprintf("/api/preview/%d", protoFile.Id),
+		}
+		if media.Video.CreationTime != nil {
+			creationTime := media.Video.CreationTime.AsTime()
+			serverFile.Video.CreationTime = &creationTime
+		}
+		if !media.Video.Playable() {
+			serverFile.Video.PlaybackURL = fmt.Sprintf("/api/video/%d/hls/%s", protoFile.Id, transcode.PlaylistName)
+			serverFile.Video.PlaybackMimeType = transcode.PlaylistMimeType
+		} else {
+			serverFile.Video.PlaybackURL = fmt.Sprintf("/api/video/%d", protoFile.Id)
+			serverFile.Video.PlaybackMimeType = media.Video.FullMimeType
+		}
+	case *pb.File_Audio:
+		serverFile.Audio = &Audio{
+			FullMimeType:    media.Audio.FullMimeType,
+			Length:          media.Audio.Length,
+			ThumbnailWidth:  media.Audio.ThumbnailWidth,
+			ThumbnailHeight: media.Audio.ThumbnailHeight,
+			BlurHash:        media.Audio.Blurhash,
+			ThumbnailURL:    fmt.Sprintf("/api/thumbnail/%d", protoFile.Id),
+			HasCoverArt:     media.Audio.HasCoverArt,
+			PlaybackURL:     fmt.Sprintf("/api/audio/%d", protoFile.Id),
+			Codec:           media.Audio.Codec,
+			Bitrate:         media.Audio.Bitrate,
+			SampleRate:      media.Audio.SampleRate,
+			Channels:        media.Audio.Channels,
+			Container:       media.Audio.Container,
+			Title:           media.Audio.Title,
+			Artist:          media.Audio.Artist,
+			Album:           media.Audio.Album,
+			Genre:           media.Audio.Genre,
+			Date:            media.Audio.Date,
+		}
+		if media.Audio.CreationTime != nil {
+			creationTime := media.Audio.CreationTime.AsTime()
+			serverFile.Audio.CreationTime = &creationTime
 		}
 	}
 
@@ -94,6 +200,7 @@ type Stats struct {
 	Count         uint64 `json:"count"`
 	ImageCount    uint64 `json:"image_count"`
 	VideoCount    uint64 `json:"video_count"`
+	AudioCount    uint64 `json:"audio_count"`
 	FavoriteCount uint64 `json:"favorite_count"`
 }
 
@@ -102,6 +209,7 @@ func MapProtoStatsToServerStats(protoStats *pb.Stats) *Stats {
 		Count:         protoStats.Count,
 		ImageCount:    protoStats.ImageCount,
 		VideoCount:    protoStats.VideoCount,
+		AudioCount:    protoStats.AudioCount,
 		FavoriteCount: protoStats.FavoriteCount,
 	}
 }
@@ -120,3 +228,49 @@ func MapProtoPaginationToServerPagination(protoPagination *pb.Pagination) *Pagin
 	}
 	return serverPagination
 }
+
+// DuplicateGroup is an indexed file with the copies scans found of it
+type DuplicateGroup struct {
+	File   *File
+	Copies []*Duplicate
+}
+
+type Duplicate struct {
+	Path    string
+	Policy  string
+	Size    int64
+	FoundAt time.Time
+}
+
+func MapProtoDuplicateToServerDuplicate(protoDuplicate *pb.Duplicate) *Duplicate {
+	return &Duplicate{
+		Path:    protoDuplicate.Path,
+		Policy:  protoDuplicate.Policy,
+		Size:    protoDuplicate.Size,
+		FoundAt: protoDuplicate.FoundAt.AsTime(),
+	}
+}
+
+type Failure struct {
+	Filename    string
+	Error       string
+	Stderr      string `json:",omitempty"`
+	Attempts    uint32
+	FirstFailed time.Time
+	LastFailed  time.Time
+	NextRetry   time.Time
+	Ignored     bool
+}
+
+func MapProtoFailureToServerFailure(protoFailure *pb.Failure) *Failure {
+	return &Failure{
+		Filename:    protoFailure.Filename,
+		Error:       protoFailure.Error,
+		Stderr:      protoFailure.Stderr,
+		Attempts:    protoFailure.Attempts,
+		FirstFailed: protoFailure.FirstFailed.AsTime(),
+		LastFailed:  protoFailure.LastFailed.AsTime(),
+		NextRetry:   protoFailure.NextRetry.AsTime(),
+		Ignored:     protoFailure.Ignored,
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"picshow/internal/files"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	scanEventInterval = time.Second
+	// Comments sent on an idle stream so proxies don't close it
+	scanKeepAliveInterval = 15 * time.Second
+)
+
+func (s *Server) getScanStatus(e echo.Context) error {
+	return e.JSON(http.StatusOK, s.processor.Status())
+}
+
+// triggerScan starts a scan without waiting for the refresh interval
+func (s *Server) triggerScan(e echo.Context) error {
+	if err := s.processor.RequestScan(); err != nil {
+		if errors.Is(err, files.ErrScanRunning) {
+			return e.JSON(http.StatusConflict, map[string]string{"error": "A scan is already running"})
+		}
+		if errors.Is(err, files.ErrOutsideScanWindow) {
+			return e.JSON(http.StatusConflict, map[string]string{"error": "Scans are only allowed between " + s.config.ScanWindow})
+		}
+		log.Errorf("Failed to request scan: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to start scan"})
+	}
+	log.Info("Scan requested through the API")
+	return e.JSON(http.StatusAccepted, s.processor.Status())
+}
+
+// scanEvents streams the scan status as server-sent events whenever it changes
+func (s *Server) scanEvents(e echo.Context) error {
+	res := e.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(scanEventInterval)
+	defer ticker.Stop()
+	var last []byte
+	lastSent := time.Now()
+	for {
+		data, err := json.Marshal(s.processor.Status())
+		if err != nil {
+			log.Errorf("Failed to marshal scan status: %v", err)
+			return nil
+		}
+		if !bytes.Equal(data, last) {
+			if _, err := fmt.Fprintf(res, "event: status\ndata: %s\n\n", data); err != nil {
+				return nil
+			}
+			res.Flush()
+			last = data
+			lastSent = time.Now()
+		} else if time.Since(lastSent) >= scanKeepAliveInterval {
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+			lastSent = time.Now()
+		}
+
+		select {
+		case <-e.Request().Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -3,15 +3,22 @@ package server
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"picshow/internal/cache"
 	"picshow/internal/config"
+	"picshow/internal/files"
 	"picshow/internal/frontend"
 	"picshow/internal/kv"
+	"picshow/internal/rendition"
+	"picshow/internal/transcode"
 	"picshow/internal/utils"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -20,18 +27,37 @@ import (
 )
 
 type Server struct {
-	e      *echo.Echo
-	repo   *kv.Repository
-	config *config.Config
-	ccache *cache.Cache
+	e          *echo.Echo
+	repo       *kv.Repository
+	config     *config.Config
+	ccache     *cache.Cache
+	processor  *files.Processor
+	transcoder *transcode.Transcoder
+	display    *rendition.Display
+	resized    *rendition.Resized
+	preview    *rendition.Preview
 }
 
 func NewServer(
 	config *config.Config,
 	repo *kv.Repository,
 	ccache *cache.Cache,
+	processor *files.Processor,
+	transcoder *transcode.Transcoder,
+	display *rendition.Display,
+	resized *rendition.Resized,
+	preview *rendition.Preview,
 ) *Server {
-	return &Server{config: config, repo: repo, ccache: ccache}
+	return &Server{
+		config:     config,
+		repo:       repo,
+		ccache:     ccache,
+		processor:  processor,
+		transcoder: transcoder,
+		display:    display,
+		resized:    resized,
+		preview:    preview,
+	}
 }
 
 func (s *Server) Start() error {
@@ -48,8 +74,15 @@ func (s *Server) Start() error {
 			return nil
 		},
 	}))
-	e.Use(middleware.Gzip())
+	e.Use(middleware.GzipWithConfig(middleware.GzipConfig{
+		// Downloads are photos and videos that don't compress any further,
+		// events have to reach the client as soon as they are written
+		Skipper: func(c echo.Context) bool {
+			return strings.HasPrefix(c.Path(), "/api/download") || c.Path() == "/api/scan/events"
+		},
+	}))
 	e.Use(middleware.CORS())
+	e.Use(s.throttleScans)
 
 	frontend.RegisterHandlers(e)
 	// API routes
@@ -57,18 +90,59 @@ func (s *Server) Start() error {
 	api.GET("/", s.getFiles)
 	api.PATCH("/:id/favorite", s.toggleFavorite)
 	api.GET("/:id/favorite", s.getFavoriteStatus)
+	api.PATCH("/:id/name", s.renameFile)
+	api.POST("/move", s.moveFiles)
+	api.GET("/folders", s.getFolders)
+	api.GET("/duplicates", s.getDuplicates)
+	api.GET("/scan/status", s.getScanStatus)
+	api.GET("/scan/events", s.scanEvents)
+	api.POST("/scan", s.triggerScan)
+	api.GET("/failures", s.getFailures)
+	api.POST("/failures/retry", s.retryFailures)
+	api.POST("/failures/ignore", s.ignoreFailures)
+	api.GET("/thumbnails/regenerate", s.getRegenerationStatus)
+	api.POST("/thumbnails/regenerate", s.regenerateThumbnails)
 	api.DELETE("/", s.deleteFiles)
 	api.GET("/image/:id", s.getImage)
+	api.GET("/download/:id", s.downloadFile)
+	api.POST("/download", s.downloadZip)
 	api.GET("/video/:id", s.streamVideo)
+	api.GET("/video/:id/hls/:name", s.streamHLS)
+	api.GET("/audio/:id", s.streamAudio)
+	api.GET("/preview/:id", s.getPreview)
+	api.GET("/thumbnail/:id", s.getThumbnail)
+	api.GET("/motion/:id", s.streamMotion)
+	api.POST("/upload", s.uploadFiles)
+	api.OPTIONS("/uploads", s.uploadOptions)
+	api.POST("/uploads", s.createUpload)
+	api.HEAD("/uploads/:uid", s.uploadOffset)
+	api.PATCH("/uploads/:uid", s.appendUpload)
+	api.DELETE("/uploads/:uid", s.cancelUpload)
 	api.GET("/stats", s.getStats)
 	api.GET("/internal/stop", s.stopDB)
 	api.GET("/internal/resume", s.resumeDB)
 
+	go s.sweepUploads()
+
 	logURLs(s.config.PORT)
 	s.e = e
 	return e.Start(fmt.Sprintf(":%d", s.config.PORT))
 }
 
+// throttleScans lets running scans know that the UI is being used so they can make way.
+// Scan and regeneration progress are left out since they are polled for as long as those run.
+func (s *Server) throttleScans(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if strings.HasPrefix(c.Path(), "/api/scan") || c.Path() == "/api/thumbnails/regenerate" {
+			return next(c)
+		}
+		scanThrottle := s.processor.Throttle()
+		scanThrottle.RequestStarted()
+		defer scanThrottle.RequestFinished()
+		return next(c)
+	}
+}
+
 func (s *Server) stopDB(c echo.Context) error {
 	log.Info("Stopping database")
 	s.repo.Close()
@@ -82,6 +156,7 @@ func (s *Server) resumeDB(c echo.Context) error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.transcoder.Shutdown()
 	return s.e.Shutdown(ctx)
 }
 
@@ -141,6 +216,10 @@ func (s *Server) getFiles(e echo.Context) error {
 	// Map protobuf Files to server Files
 	serverFiles := make([]*File, len(files))
 	for i, protoFile := range files {
+		// Videos indexed without their codecs are transcoded unless they are probed first
+		if err := s.processor.ProbeCodecs(protoFile); err != nil {
+			log.Warnf("Failed to read the codecs of %s: %v", protoFile.Filename, err)
+		}
 		serverFiles[i] = MapProtoFileToServerFile(protoFile)
 	}
 
@@ -186,22 +265,74 @@ func (s *Server) getImage(e echo.Context) error {
 	if file.MimeType == utils.MimeTypeImage.String() {
 		filePath := filepath.Join(s.config.FolderPath, file.Filename)
 
-		// Read file contents
-		fileContents, err := os.ReadFile(filePath)
-		if err != nil {
-			log.Errorf("Failed to read file: %v", err)
-			return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to read file"})
+		query := &imageQuery{}
+		if err := e.Bind(query); err != nil {
+			log.Errorf("Failed to parse query: %v", err)
+			return e.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to parse query"})
+		}
+		mimeType := file.GetImage().GetFullMimeType()
+		if file.GetImage().GetHasDisplayRendition() {
+			filePath, err = s.display.Ensure(filePath, file.Hash)
+			if err != nil {
+				log.Errorf("Failed to create display rendition: %v", err)
+				return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to convert image"})
+			}
+			mimeType = rendition.DisplayMimeType
+		}
+		if width := rendition.SnapWidth(query.Width, file.GetImage().GetWidth()); query.Width > 0 && width > 0 {
+			format := query.format(e.Request().Header.Get(echo.HeaderAccept))
+			renditionPath, err := s.resized.Ensure(filePath, file.Hash, width, format)
+			if err != nil {
+				log.Errorf("Failed to create resized rendition: %v", err)
+				return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to resize image"})
+			}
+			e.Response().Header().Set("Vary", echo.HeaderAccept)
+			log.Debugf("Serving %dpx rendition of image file: %s", width, file.Filename)
+			return serveFile(e, renditionPath, format.MimeType())
+		}
+		if query.Orient == "auto" && utils.Orientation(file.GetImage().GetOrientation()).NeedsAutoOrient() {
+			log.Debugf("Serving auto-oriented image file: %s", file.Filename)
+			return s.streamAutoOriented(e, filePath, mimeType)
 		}
 
-		// Serve the file
 		log.Debugf("Serving image file: %s", file.Filename)
-		return e.Blob(http.StatusOK, http.DetectContentType(fileContents), fileContents)
+		return serveFile(e, filePath, mimeType)
 	} else {
 		log.Warnf("Unsupported mimetype for file ID: %d", fileId)
 		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Unsupported mimetype"})
 	}
 }
 
+// serveFile streams a file from disk, with range support, instead of buffering it in memory
+func serveFile(e echo.Context, filePath, mimeType string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		log.Errorf("Failed to open file: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to open file"})
+	}
+	defer f.Close()
+	e.Response().Header().Set(echo.HeaderContentType, mimeType)
+	// Last-Modified is already set from the indexed file
+	http.ServeContent(e.Response(), e.Request(), filepath.Base(filePath), time.Time{}, f)
+	return nil
+}
+
+// streamAutoOriented rotates the pixels according to the EXIF orientation for clients that ignore it
+func (s *Server) streamAutoOriented(e echo.Context, filePath, mimeType string) error {
+	cmd := exec.CommandContext(e.Request().Context(), "convert", filePath, "-auto-orient", "-")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		log.Errorf("Failed to create stdout pipe: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to orient image"})
+	}
+	if err := cmd.Start(); err != nil {
+		log.Errorf("Failed to start convert: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to orient image"})
+	}
+	defer cmd.Wait()
+	return e.Stream(http.StatusOK, mimeType, stdout)
+}
+
 func (s *Server) streamVideo(e echo.Context) error {
 	id := e.Param("id")
 	fileId, err := strconv.ParseUint(id, 10, 64)
@@ -235,6 +366,210 @@ func (s *Server) streamVideo(e echo.Context) error {
 	return e.Stream(http.StatusOK, file.GetVideo().FullMimeType, f)
 }
 
+func (s *Server) streamAudio(e echo.Context) error {
+	id := e.Param("id")
+	fileId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Errorf("Invalid file ID: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid file id"})
+	}
+	file, err := s.repo.GetFileByID(fileId)
+	if err != nil {
+		log.Errorf("Failed to fetch file from repository: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch file"})
+	}
+	if file.GetAudio() == nil {
+		log.Warnf("Unsupported mimetype for file ID: %d", fileId)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Unsupported mimetype"})
+	}
+
+	e.Response().Header().Set("Cache-Control", "public, max-age=259200")
+	lastModified := time.Unix(file.LastModified, 0).UTC().Format(http.TimeFormat)
+	e.Response().Header().Set("Last-Modified", lastModified)
+
+	if ifModifiedSince := e.Request().Header.Get("If-Modified-Since"); ifModifiedSince != "" {
+		ifModifiedSinceTime, err := time.Parse(http.TimeFormat, ifModifiedSince)
+		if err == nil && !time.Unix(file.LastModified, 0).After(ifModifiedSinceTime) {
+			log.Debugf("Returning 304 Not Modified for file ID: %d", fileId)
+			return e.NoContent(http.StatusNotModified)
+		}
+	}
+	// Players seek with range requests, serveFile answers them
+	log.Debugf("Streaming audio file: %s", file.Filename)
+	return serveFile(e, filepath.Join(s.config.FolderPath, file.Filename), file.GetAudio().FullMimeType)
+}
+
+// getPreview serves the looped preview of a video or an animated image
+func (s *Server) getPreview(e echo.Context) error {
+	id := e.Param("id")
+	fileId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Errorf("Invalid file ID: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid file id"})
+	}
+	file, err := s.repo.GetFileByID(fileId)
+	if err != nil {
+		log.Errorf("Failed to fetch file from repository: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch file"})
+	}
+
+	filePath := filepath.Join(s.config.FolderPath, file.Filename)
+	var previewPath, mimeType string
+	switch {
+	case file.GetVideo() != nil:
+		previewPath, err = s.preview.EnsureClip(filePath, file.Hash)
+		mimeType = rendition.ClipMimeType
+	case file.GetImage().GetAnimated():
+		previewPath, err = s.preview.EnsureAnimation(filePath, file.Hash, file.GetImage().GetFullMimeType())
+		mimeType = rendition.AnimationMimeType
+	default:
+		return e.JSON(http.StatusNotFound, map[string]string{"error": "No preview for this file"})
+	}
+	if err != nil {
+		log.Errorf("Failed to create preview of %s: %v", file.Filename, err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create preview"})
+	}
+	e.Response().Header().Set("Cache-Control", "public, max-age=259200")
+	e.Response().Header().Set("Last-Modified", time.Unix(file.LastModified, 0).UTC().Format(http.TimeFormat))
+	log.Debugf("Serving preview of %s", file.Filename)
+	return serveFile(e, previewPath, mimeType)
+}
+
+// getThumbnail serves the thumbnail of a file in the size asked for, as WebP when the browser takes it
+func (s *Server) getThumbnail(e echo.Context) error {
+	id := e.Param("id")
+	fileId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Errorf("Invalid file ID: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid file id"})
+	}
+	query := &thumbnailQuery{}
+	if err := e.Bind(query); err != nil {
+		log.Errorf("Failed to parse query: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to parse query"})
+	}
+	size, ok := query.size()
+	if !ok {
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown thumbnail size"})
+	}
+	file, err := s.repo.GetFileByID(fileId)
+	if err != nil {
+		log.Errorf("Failed to fetch file from repository: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch file"})
+	}
+
+	e.Response().Header().Set("Cache-Control", "public, max-age=259200")
+	e.Response().Header().Set("Last-Modified", time.Unix(file.LastModified, 0).UTC().Format(http.TimeFormat))
+	e.Response().Header().Set("Vary", "Accept")
+
+	// Files whose other sizes couldn't be made only have the large JPEG
+	format := query.format(e.Request().Header.Get("Accept"))
+	candidates := []struct {
+		size   utils.ThumbnailSize
+		format rendition.Format
+	}{{size, format}, {size, rendition.JPEG}, {utils.ThumbnailLarge, rendition.JPEG}}
+	for _, candidate := range candidates {
+		data, found, err := s.repo.GetThumbnail(file.Hash, candidate.size.String(), string(candidate.format))
+		if err != nil {
+			return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch thumbnail"})
+		}
+		if found {
+			return e.Blob(http.StatusOK, candidate.format.MimeType(), data)
+		}
+	}
+	return e.JSON(http.StatusNotFound, map[string]string{"error": "No thumbnail for this file"})
+}
+
+// streamMotion serves the video of a Live Photo or of a motion photo
+func (s *Server) streamMotion(e echo.Context) error {
+	id := e.Param("id")
+	fileId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Errorf("Invalid file ID: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid file id"})
+	}
+	file, err := s.repo.GetFileByID(fileId)
+	if err != nil {
+		log.Errorf("Failed to fetch file from repository: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch file"})
+	}
+	image := file.GetImage()
+	if !image.GetMotion() {
+		return e.JSON(http.StatusNotFound, map[string]string{"error": "No motion for this file"})
+	}
+	e.Response().Header().Set("Cache-Control", "public, max-age=259200")
+	e.Response().Header().Set("Last-Modified", time.Unix(file.LastModified, 0).UTC().Format(http.TimeFormat))
+
+	if videoID := image.PairedVideo(); videoID != nil {
+		video, err := s.repo.GetFileByID(*videoID)
+		if err != nil {
+			log.Errorf("Failed to fetch motion of file %d: %v", fileId, err)
+			return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch file"})
+		}
+		log.Debugf("Streaming motion of %s from %s", file.Filename, video.Filename)
+		return serveFile(e, filepath.Join(s.config.FolderPath, video.Filename), video.GetVideo().GetFullMimeType())
+	}
+
+	// The video of a motion photo is the end of the JPEG
+	f, err := os.Open(filepath.Join(s.config.FolderPath, file.Filename))
+	if err != nil {
+		log.Errorf("Failed to open file: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to open file"})
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		log.Errorf("Failed to stat file: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to open file"})
+	}
+	log.Debugf("Streaming motion embedded in %s", file.Filename)
+	e.Response().Header().Set(echo.HeaderContentType, "video/mp4")
+	video := io.NewSectionReader(f, image.GetMotionOffset(), info.Size()-image.GetMotionOffset())
+	http.ServeContent(e.Response(), e.Request(), "", time.Time{}, video)
+	return nil
+}
+
+func (s *Server) streamHLS(e echo.Context) error {
+	id := e.Param("id")
+	fileId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		log.Errorf("Invalid file ID: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid file id"})
+	}
+	file, err := s.repo.GetFileByID(fileId)
+	if err != nil {
+		log.Errorf("Failed to fetch file from repository: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch file"})
+	}
+	if file.GetVideo() == nil {
+		log.Warnf("Unsupported mimetype for file ID: %d", fileId)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Unsupported mimetype"})
+	}
+
+	name := e.Param("name")
+	if name == transcode.PlaylistName {
+		playlistPath, err := s.transcoder.Playlist(e.Request().Context(), file.Hash, filepath.Join(s.config.FolderPath, file.Filename))
+		if err != nil {
+			log.Errorf("Failed to transcode video %s: %v", file.Filename, err)
+			return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to transcode video"})
+		}
+		// The playlist grows while the transcode is running
+		e.Response().Header().Set("Cache-Control", "no-cache")
+		e.Response().Header().Set(echo.HeaderContentType, transcode.PlaylistMimeType)
+		log.Debugf("Serving HLS playlist for video file: %s", file.Filename)
+		return e.File(playlistPath)
+	}
+
+	segmentPath, err := s.transcoder.Segment(file.Hash, name)
+	if err != nil {
+		log.Errorf("Failed to find HLS segment %s for %s: %v", name, file.Filename, err)
+		return e.JSON(http.StatusNotFound, map[string]string{"error": "Segment not found"})
+	}
+	e.Response().Header().Set("Cache-Control", "public, max-age=259200")
+	e.Response().Header().Set(echo.HeaderContentType, transcode.SegmentMimeType)
+	return e.File(segmentPath)
+}
+
 func (s *Server) getStats(c echo.Context) error {
 	stats, err := s.repo.GetStats()
 	if err != nil {
@@ -257,6 +592,21 @@ func (s *Server) deleteFiles(e echo.Context) error {
 		log.Errorf("Failed to fetch files from repository: %v", err)
 		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch files"})
 	}
+	// Live Photos go with their video
+	var motionIDs []uint64
+	for _, file := range files {
+		if videoID := file.GetImage().PairedVideo(); videoID != nil && !slices.Contains(idsToDelete, *videoID) {
+			motionIDs = append(motionIDs, *videoID)
+		}
+	}
+	if len(motionIDs) > 0 {
+		motionFiles, err := s.repo.GetFilesByIds(motionIDs)
+		if err != nil {
+			log.Errorf("Failed to fetch files from repository: %v", err)
+			return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch files"})
+		}
+		files = append(files, motionFiles...)
+	}
 	fileIDs := make([]uint64, 0)
 	for _, file := range files {
 		fileIDs = append(fileIDs, file.Id)
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"picshow/internal/files"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// getRegenerationStatus reports the progress of the current or last thumbnail regeneration
+func (s *Server) getRegenerationStatus(e echo.Context) error {
+	return e.JSON(http.StatusOK, s.processor.RegenerationStatus())
+}
+
+// regenerateThumbnails starts making the thumbnails of the files the filter picks again,
+// its progress is polled from getRegenerationStatus
+func (s *Server) regenerateThumbnails(e echo.Context) error {
+	filter := files.RegenerateFilter{}
+	if err := e.Bind(&filter); err != nil {
+		log.Errorf("Failed to parse regeneration request body: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to parse request body"})
+	}
+	if err := filter.Validate(); err != nil {
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err := s.processor.StartRegeneration(filter); err != nil {
+		if errors.Is(err, files.ErrRegenerationRunning) {
+			return e.JSON(http.StatusConflict, map[string]string{"error": "Thumbnails are already being regenerated"})
+		}
+		log.Errorf("Failed to start thumbnail regeneration: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to start thumbnail regeneration"})
+	}
+	log.Info("Thumbnail regeneration requested through the API")
+	return e.JSON(http.StatusAccepted, s.processor.RegenerationStatus())
+}
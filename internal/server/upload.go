@@ -0,0 +1,425 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"picshow/internal/files"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// Uploads are written next to their destination under a hidden name that scans skip,
+// then moved into place once they are complete and known not to be duplicates
+const uploadTempPrefix = files.TempFilePrefix + "upload-"
+
+const tusVersion = "1.0.0"
+
+// Resumable uploads that get no data for uploadExpiry are removed, they were abandoned by their client
+const (
+	uploadExpiry        = 24 * time.Hour
+	uploadSweepInterval = time.Hour
+)
+
+var uploadIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Resumable uploads that are receiving data, a second PATCH for the same upload is refused
+var activeUploads sync.Map
+
+type uploadResult struct {
+	Name      string
+	File      *File  `json:",omitempty"`
+	Duplicate *File  `json:",omitempty"`
+	Error     string `json:",omitempty"`
+}
+
+// uploadInfo is what we keep next to the data of a resumable upload
+type uploadInfo struct {
+	Filename string
+	Length   int64
+}
+
+// uploadFiles takes a multipart form and indexes each file part as soon as it is received
+func (s *Server) uploadFiles(e echo.Context) error {
+	reader, err := e.Request().MultipartReader()
+	if err != nil {
+		log.Errorf("Failed to read multipart upload: %v", err)
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Expected a multipart form"})
+	}
+	if err := os.MkdirAll(s.config.UploadPath(), 0755); err != nil {
+		log.Errorf("Failed to create upload folder: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create upload folder"})
+	}
+
+	results := make([]uploadResult, 0)
+	duplicates := 0
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Errorf("Failed to read multipart upload: %v", err)
+			return e.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read upload"})
+		}
+		if part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		result := s.receiveUpload(part, part.FileName())
+		part.Close()
+		if result.Duplicate != nil {
+			duplicates++
+		}
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "No files in upload"})
+	}
+	if duplicates == len(results) {
+		return e.JSON(http.StatusConflict, results)
+	}
+	return e.JSON(http.StatusOK, results)
+}
+
+// receiveUpload writes one uploaded file to a temporary file and ingests it
+func (s *Server) receiveUpload(r io.Reader, name string) uploadResult {
+	name = sanitizeUploadName(name)
+	result := uploadResult{Name: name}
+	id, err := newUploadID()
+	if err != nil {
+		log.Errorf("Failed to create upload id: %v", err)
+		result.Error = "Failed to store upload"
+		return result
+	}
+	tempPath := s.uploadDataPath(id, name)
+	defer os.Remove(tempPath)
+
+	f, err := os.Create(tempPath)
+	if err != nil {
+		log.Errorf("Failed to create upload file: %v", err)
+		result.Error = "Failed to store upload"
+		return result
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Errorf("Failed to write upload %s: %v", name, err)
+		result.Error = "Failed to store upload"
+		return result
+	}
+	return s.ingestUpload(tempPath, name)
+}
+
+func (s *Server) ingestUpload(tempPath, name string) uploadResult {
+	result := uploadResult{Name: name}
+	file, err := s.processor.Ingest(tempPath, filepath.Join(s.config.UploadPath(), name))
+	var duplicate *files.DuplicateError
+	switch {
+	case errors.As(err, &duplicate):
+		log.Infof("Rejected upload %s, duplicate of %s", name, duplicate.Existing.Filename)
+		result.Duplicate = MapProtoFileToServerFile(duplicate.Existing)
+		result.Error = "File is already in the library"
+	case errors.Is(err, files.ErrUnsupported):
+		result.Error = "Only images and videos can be uploaded"
+	case errors.Is(err, files.ErrExcluded):
+		result.Error = "File is excluded from the library by the scan settings"
+	case err != nil:
+		log.Errorf("Failed to ingest upload %s: %v", name, err)
+		result.Error = "Failed to process upload"
+	default:
+		result.File = MapProtoFileToServerFile(file)
+	}
+	return result
+}
+
+// uploadOptions advertises the tus protocol subset we support
+func (s *Server) uploadOptions(e echo.Context) error {
+	e.Response().Header().Set("Tus-Resumable", tusVersion)
+	e.Response().Header().Set("Tus-Version", tusVersion)
+	e.Response().Header().Set("Tus-Extension", "creation,termination,expiration")
+	return e.NoContent(http.StatusNoContent)
+}
+
+// createUpload starts a resumable upload, the data follows in PATCH requests
+func (s *Server) createUpload(e echo.Context) error {
+	e.Response().Header().Set("Tus-Resumable", tusVersion)
+	length, err := strconv.ParseInt(e.Request().Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid Upload-Length"})
+	}
+	name := sanitizeUploadName(parseUploadMetadata(e.Request().Header.Get("Upload-Metadata"))["filename"])
+	if err := os.MkdirAll(s.config.UploadPath(), 0755); err != nil {
+		log.Errorf("Failed to create upload folder: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create upload folder"})
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		log.Errorf("Failed to create upload id: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create upload"})
+	}
+	info := uploadInfo{Filename: name, Length: length}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create upload"})
+	}
+	if err := os.WriteFile(s.uploadInfoPath(id), data, 0644); err != nil {
+		log.Errorf("Failed to write upload info: %v", err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create upload"})
+	}
+	if err := os.WriteFile(s.uploadDataPath(id, name), nil, 0644); err != nil {
+		log.Errorf("Failed to create upload file: %v", err)
+		os.Remove(s.uploadInfoPath(id))
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create upload"})
+	}
+	log.Infof("Started upload %s of %s (%d bytes)", id, name, length)
+	e.Response().Header().Set(echo.HeaderLocation, "/api/uploads/"+id)
+	setUploadExpires(e, time.Now())
+	return e.NoContent(http.StatusCreated)
+}
+
+// uploadOffset tells the client how much of the upload we already have
+func (s *Server) uploadOffset(e echo.Context) error {
+	e.Response().Header().Set("Tus-Resumable", tusVersion)
+	e.Response().Header().Set("Cache-Control", "no-store")
+	id, info, err := s.loadUpload(e.Param("uid"))
+	if err != nil {
+		return e.NoContent(http.StatusNotFound)
+	}
+	stat, err := os.Stat(s.uploadDataPath(id, info.Filename))
+	if err != nil {
+		return e.NoContent(http.StatusNotFound)
+	}
+	e.Response().Header().Set("Upload-Offset", strconv.FormatInt(stat.Size(), 10))
+	e.Response().Header().Set("Upload-Length", strconv.FormatInt(info.Length, 10))
+	setUploadExpires(e, stat.ModTime())
+	return e.NoContent(http.StatusOK)
+}
+
+// appendUpload adds a chunk at the end of the upload and ingests it once it is complete
+func (s *Server) appendUpload(e echo.Context) error {
+	e.Response().Header().Set("Tus-Resumable", tusVersion)
+	if e.Request().Header.Get(echo.HeaderContentType) != "application/offset+octet-stream" {
+		return e.JSON(http.StatusUnsupportedMediaType, map[string]string{"error": "Expected application/offset+octet-stream"})
+	}
+	id, info, err := s.loadUpload(e.Param("uid"))
+	if err != nil {
+		return e.JSON(http.StatusNotFound, map[string]string{"error": "Upload not found"})
+	}
+	if _, busy := activeUploads.LoadOrStore(id, true); busy {
+		return e.JSON(http.StatusLocked, map[string]string{"error": "Upload is already receiving data"})
+	}
+	defer activeUploads.Delete(id)
+
+	dataPath := s.uploadDataPath(id, info.Filename)
+	f, err := os.OpenFile(dataPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return e.JSON(http.StatusNotFound, map[string]string{"error": "Upload not found"})
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to read upload"})
+	}
+	offset, err := strconv.ParseInt(e.Request().Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset != stat.Size() {
+		f.Close()
+		e.Response().Header().Set("Upload-Offset", strconv.FormatInt(stat.Size(), 10))
+		return e.JSON(http.StatusConflict, map[string]string{"error": "Upload-Offset doesn't match the upload"})
+	}
+
+	// Whatever arrived before the connection dropped is kept so the client can resume from there
+	written, err := io.Copy(f, io.LimitReader(e.Request().Body, info.Length-offset))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	offset += written
+	e.Response().Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	if err != nil {
+		log.Errorf("Failed to write upload %s: %v", id, err)
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to store upload"})
+	}
+	if offset < info.Length {
+		setUploadExpires(e, time.Now())
+		return e.NoContent(http.StatusNoContent)
+	}
+
+	result := s.ingestUpload(dataPath, info.Filename)
+	s.removeUpload(id, info.Filename)
+	switch {
+	case result.Duplicate != nil:
+		return e.JSON(http.StatusConflict, result)
+	case result.File == nil:
+		return e.JSON(http.StatusUnprocessableEntity, result)
+	}
+	e.Response().Header().Set("Upload-File-Id", strconv.FormatUint(result.File.ID, 10))
+	return e.NoContent(http.StatusNoContent)
+}
+
+// cancelUpload drops an unfinished resumable upload
+func (s *Server) cancelUpload(e echo.Context) error {
+	e.Response().Header().Set("Tus-Resumable", tusVersion)
+	id, info, err := s.loadUpload(e.Param("uid"))
+	if err != nil {
+		return e.NoContent(http.StatusNotFound)
+	}
+	if _, busy := activeUploads.Load(id); busy {
+		return e.JSON(http.StatusLocked, map[string]string{"error": "Upload is receiving data"})
+	}
+	s.removeUpload(id, info.Filename)
+	log.Infof("Cancelled upload %s", id)
+	return e.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) loadUpload(id string) (string, *uploadInfo, error) {
+	if !uploadIDPattern.MatchString(id) {
+		return "", nil, fmt.Errorf("invalid upload id %q", id)
+	}
+	data, err := os.ReadFile(s.uploadInfoPath(id))
+	if err != nil {
+		return "", nil, err
+	}
+	info := new(uploadInfo)
+	if err := json.Unmarshal(data, info); err != nil {
+		return "", nil, err
+	}
+	stat, err := os.Stat(s.uploadDataPath(id, info.Filename))
+	if err != nil {
+		return "", nil, err
+	}
+	if _, busy := activeUploads.Load(id); !busy && time.Since(stat.ModTime()) > uploadExpiry {
+		s.removeUpload(id, info.Filename)
+		return "", nil, fmt.Errorf("upload %s expired", id)
+	}
+	return id, info, nil
+}
+
+// setUploadExpires tells the client how long an upload that last got data at lastWrite is kept
+func setUploadExpires(e echo.Context, lastWrite time.Time) {
+	e.Response().Header().Set("Upload-Expires", lastWrite.Add(uploadExpiry).UTC().Format(http.TimeFormat))
+}
+
+// sweepUploads removes expired uploads now and then for as long as the server runs
+func (s *Server) sweepUploads() {
+	ticker := time.NewTicker(uploadSweepInterval)
+	defer ticker.Stop()
+	for {
+		s.removeExpiredUploads()
+		<-ticker.C
+	}
+}
+
+// removeExpiredUploads removes the resumable uploads that got no data for uploadExpiry, and the
+// data of uploads cut off by a restart
+func (s *Server) removeExpiredUploads() {
+	entries, err := os.ReadDir(s.config.UploadPath())
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Errorf("Failed to list upload folder: %v", err)
+		}
+		return
+	}
+	// The data and the info of an upload share its id, it expires with the last one written
+	lastWrites := make(map[string]time.Time)
+	names := make(map[string][]string)
+	for _, entry := range entries {
+		id, found := strings.CutPrefix(entry.Name(), uploadTempPrefix)
+		if !found || len(id) < 32 || !uploadIDPattern.MatchString(id[:32]) {
+			continue
+		}
+		id = id[:32]
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(lastWrites[id]) {
+			lastWrites[id] = info.ModTime()
+		}
+		names[id] = append(names[id], entry.Name())
+	}
+	for id, lastWrite := range lastWrites {
+		if _, busy := activeUploads.Load(id); busy || time.Since(lastWrite) <= uploadExpiry {
+			continue
+		}
+		log.Infof("Removing upload %s, it got no data since %s", id, lastWrite.Format(time.RFC3339))
+		for _, name := range names[id] {
+			if err := os.Remove(filepath.Join(s.config.UploadPath(), name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Errorf("Failed to remove expired upload file %s: %v", name, err)
+			}
+		}
+	}
+}
+
+func (s *Server) removeUpload(id, name string) {
+	os.Remove(s.uploadDataPath(id, name))
+	os.Remove(s.uploadInfoPath(id))
+}
+
+// The data file keeps the extension of the upload, RAW files are recognized by it
+func (s *Server) uploadDataPath(id, name string) string {
+	return filepath.Join(s.config.UploadPath(), uploadTempPrefix+id+strings.ToLower(filepath.Ext(name)))
+}
+
+func (s *Server) uploadInfoPath(id string) string {
+	return filepath.Join(s.config.UploadPath(), uploadTempPrefix+id+".json")
+}
+
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// sanitizeUploadName keeps the base name of what the client sent, without leading dots
+// since hidden files are skipped by scans
+func sanitizeUploadName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimLeft(name, ". ")
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "upload"
+	}
+	return name
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header, comma-separated keys with base64 values
+func parseUploadMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		metadata[key] = string(decoded)
+	}
+	return metadata
+}
@@ -0,0 +1,175 @@
+// Package throttle keeps scans from taking over the machine, which matters on small boards
+// where a few ffmpeg and convert processes are enough to make the web UI unusable.
+package throttle
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"picshow/internal/config"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// How often a paused scan checks whether it can go on
+const pollInterval = 500 * time.Millisecond
+
+// Reasons a scan is paused for
+const (
+	PausedRequests = "requests"
+	PausedLoad     = "load"
+	PausedWindow   = "window"
+)
+
+// Throttle decides when scans may process their next file and lowers the priority of the tools they run
+type Throttle struct {
+	config  *config.Config
+	window  *Window
+	limiter *rate.Limiter
+
+	// requests counts the HTTP requests being served, lastRequest is when the last one ended
+	requests    atomic.Int64
+	lastRequest atomic.Int64
+	paused      atomic.Value
+
+	lookupOnce sync.Once
+	nicePath   string
+	ionicePath string
+}
+
+func New(config *config.Config) *Throttle {
+	t := &Throttle{config: config}
+	t.paused.Store("")
+	if config.ScanWindow != "" {
+		window, err := ParseWindow(config.ScanWindow)
+		if err != nil {
+			log.Errorf("Ignoring ScanWindow, scans are allowed at any time: %v", err)
+		} else {
+			t.window = window
+		}
+	}
+	if config.MaxFilesPerMinute > 0 {
+		t.limiter = rate.NewLimiter(rate.Limit(float64(config.MaxFilesPerMinute)/60), 1)
+	}
+	return t
+}
+
+// InWindow tells whether scans are allowed at t
+func (t *Throttle) InWindow(now time.Time) bool {
+	return t.window == nil || t.window.Contains(now)
+}
+
+// NextWindow returns when scans are allowed next, now when they already are
+func (t *Throttle) NextWindow(now time.Time) time.Time {
+	if t.window == nil {
+		return now
+	}
+	return t.window.Next(now)
+}
+
+// RequestStarted and RequestFinished surround every HTTP request the server handles
+func (t *Throttle) RequestStarted() {
+	t.requests.Add(1)
+}
+
+func (t *Throttle) RequestFinished() {
+	t.lastRequest.Store(time.Now().UnixNano())
+	t.requests.Add(-1)
+}
+
+// Paused returns why scans are waiting, or an empty string when they aren't
+func (t *Throttle) Paused() string {
+	return t.paused.Load().(string)
+}
+
+// Wait blocks until the next file may be processed, it only returns early when ctx is done
+func (t *Throttle) Wait(ctx context.Context) error {
+	for {
+		reason := t.pauseReason(time.Now())
+		if reason == "" {
+			break
+		}
+		if t.paused.Swap(reason) != reason {
+			log.Infof("Pausing scan (%s)", reason)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+	if t.paused.Swap("") != "" {
+		log.Info("Resuming scan")
+	}
+	if t.limiter != nil {
+		return t.limiter.Wait(ctx)
+	}
+	return nil
+}
+
+func (t *Throttle) pauseReason(now time.Time) string {
+	if !t.InWindow(now) {
+		return PausedWindow
+	}
+	if pause := time.Duration(t.config.RequestPauseSeconds) * time.Second; pause > 0 {
+		if t.requests.Load() > 0 || now.Sub(time.Unix(0, t.lastRequest.Load())) < pause {
+			return PausedRequests
+		}
+	}
+	if t.config.MaxLoadAverage > 0 {
+		if load, err := loadAverage(); err == nil && load > t.config.MaxLoadAverage {
+			return PausedLoad
+		}
+	}
+	return ""
+}
+
+// loadAverage reads the one minute load average, only Linux provides it
+func loadAverage() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// Prepare makes a command that hasn't started yet run under nice and ionice with the
+// configured niceness. Either tool is skipped when it isn't installed.
+func (t *Throttle) Prepare(cmd *exec.Cmd) {
+	if t.config.ToolNiceness <= 0 {
+		return
+	}
+	t.lookupOnce.Do(func() {
+		t.nicePath, _ = exec.LookPath("nice")
+		t.ionicePath, _ = exec.LookPath("ionice")
+		if t.nicePath == "" && t.ionicePath == "" {
+			log.Warn("Neither nice nor ionice were found, scan tools run at normal priority")
+		}
+	})
+	// Both exec the tool in place so killing the process still kills the tool
+	if t.ionicePath != "" {
+		cmd.Args = append([]string{"ionice", "-c", "2", "-n", "7", cmd.Path}, cmd.Args[1:]...)
+		cmd.Path = t.ionicePath
+	}
+	if t.nicePath != "" {
+		cmd.Args = append([]string{"nice", "-n", strconv.Itoa(t.config.ToolNiceness), cmd.Path}, cmd.Args[1:]...)
+		cmd.Path = t.nicePath
+	}
+}
+
+// Command is exec.Command for a tool a scan runs
+func (t *Throttle) Command(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	t.Prepare(cmd)
+	return cmd
+}
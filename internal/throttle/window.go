@@ -0,0 +1,71 @@
+package throttle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time range like 01:00-06:00, it spans midnight when it ends before it starts
+type Window struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// ParseWindow reads a window written as HH:MM-HH:MM in local time
+func ParseWindow(s string) (*Window, error) {
+	startText, endText, found := strings.Cut(s, "-")
+	if !found {
+		return nil, fmt.Errorf("invalid time window %q, expected HH:MM-HH:MM", s)
+	}
+	start, err := parseClock(startText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	end, err := parseClock(endText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("invalid time window %q, it starts when it ends", s)
+	}
+	return &Window{start: start, end: end}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains tells whether t falls inside the window
+func (w *Window) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+	if w.start < w.end {
+		return offset >= w.start && offset < w.end
+	}
+	return offset >= w.start || offset < w.end
+}
+
+// Next returns t when it is inside the window, the time the window opens next otherwise
+func (w *Window) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	next := midnight.Add(w.start)
+	if !next.After(t) {
+		next = midnight.AddDate(0, 0, 1).Add(w.start)
+	}
+	return next
+}
+
+func (w *Window) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", int(w.start.Hours()), int(w.start.Minutes())%60, int(w.end.Hours()), int(w.end.Minutes())%60)
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
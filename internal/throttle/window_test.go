@@ -0,0 +1,46 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	at := func(clock string) time.Time {
+		parsed, err := time.Parse("15:04", clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Date(2024, 3, 10, parsed.Hour(), parsed.Minute(), 0, 0, time.Local)
+	}
+
+	overnight, err := ParseWindow("23:30-06:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for clock, inside := range map[string]bool{"23:30": true, "02:00": true, "06:00": false, "12:00": false, "23:29": false} {
+		if got := overnight.Contains(at(clock)); got != inside {
+			t.Errorf("Contains(%s) = %v, want %v", clock, got, inside)
+		}
+	}
+	if got, want := overnight.Next(at("12:00")), at("23:30"); !got.Equal(want) {
+		t.Errorf("Next(12:00) = %v, want %v", got, want)
+	}
+
+	daytime, err := ParseWindow("09:00-17:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := daytime.Next(at("18:00")), at("09:00").AddDate(0, 0, 1); !got.Equal(want) {
+		t.Errorf("Next(18:00) = %v, want %v", got, want)
+	}
+	if got := daytime.Next(at("10:00")); !got.Equal(at("10:00")) {
+		t.Errorf("Next(10:00) = %v, want now", got)
+	}
+
+	for _, invalid := range []string{"", "01:00", "25:00-06:00", "01:00-01:00"} {
+		if _, err := ParseWindow(invalid); err == nil {
+			t.Errorf("ParseWindow(%q) succeeded", invalid)
+		}
+	}
+}
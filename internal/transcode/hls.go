@@ -0,0 +1,201 @@
+package transcode
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"picshow/internal/diskcache"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	PlaylistName     = "index.m3u8"
+	PlaylistMimeType = "application/vnd.apple.mpegurl"
+	SegmentMimeType  = "video/mp2t"
+	segmentPattern   = "segment_%05d.ts"
+)
+
+// Transcoder converts videos that browsers can't play into H.264/AAC HLS streams.
+// Renditions are produced on demand and kept in a size-bounded disk cache keyed by the file hash.
+type Transcoder struct {
+	cache *diskcache.Cache
+	mu    sync.Mutex
+	jobs  map[string]*job
+}
+
+type job struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
+func NewTranscoder(cache *diskcache.Cache) *Transcoder {
+	return &Transcoder{
+		cache: cache,
+		jobs:  make(map[string]*job),
+	}
+}
+
+// Playlist makes sure an HLS rendition of srcPath exists or is being produced
+// and returns the playlist path once the first segment is ready to be played
+func (t *Transcoder) Playlist(ctx context.Context, hash, srcPath string) (string, error) {
+	playlistPath := filepath.Join(t.cache.Path(hash), PlaylistName)
+	if t.isComplete(playlistPath) {
+		t.cache.Touch(hash)
+		return playlistPath, nil
+	}
+
+	j := t.start(hash, srcPath)
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-j.done:
+			if j.err != nil {
+				return "", j.err
+			}
+			return playlistPath, nil
+		case <-ticker.C:
+			if hasSegment(playlistPath) {
+				return playlistPath, nil
+			}
+		}
+	}
+}
+
+// Segment returns the path of a segment of an already started rendition
+func (t *Transcoder) Segment(hash, name string) (string, error) {
+	if name != filepath.Base(name) || !strings.HasSuffix(name, ".ts") {
+		return "", fmt.Errorf("invalid segment name %s", name)
+	}
+	segmentPath := filepath.Join(t.cache.Path(hash), name)
+	if _, err := os.Stat(segmentPath); err != nil {
+		return "", err
+	}
+	t.cache.Touch(hash)
+	return segmentPath, nil
+}
+
+// Shutdown stops all running transcodes
+func (t *Transcoder) Shutdown() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for hash, j := range t.jobs {
+		if j.cmd.Process != nil {
+			log.Debugf("Terminating transcode of %s", hash)
+			if err := j.cmd.Process.Kill(); err != nil {
+				log.Errorf("Error killing transcode process: %v", err)
+			}
+		}
+	}
+}
+
+func (t *Transcoder) start(hash, srcPath string) *job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if j, ok := t.jobs[hash]; ok {
+		return j
+	}
+
+	outDir := t.cache.Path(hash)
+	j := &job{done: make(chan struct{})}
+	if t.isComplete(filepath.Join(outDir, PlaylistName)) {
+		close(j.done)
+		return j
+	}
+	// A leftover folder without an end marker comes from an interrupted transcode
+	if err := os.RemoveAll(outDir); err != nil {
+		j.err = fmt.Errorf("error clearing transcode folder: %w", err)
+		close(j.done)
+		return j
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		j.err = fmt.Errorf("error creating transcode folder: %w", err)
+		close(j.done)
+		return j
+	}
+
+	j.cmd = exec.Command(
+		"ffmpeg",
+		"-i", srcPath,
+		"-map", "0:v:0",
+		"-map", "0:a:0?",
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-crf", "23",
+		"-profile:v", "main",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-b:a", "128k",
+		"-ac", "2",
+		"-f", "hls",
+		"-hls_time", "6",
+		"-hls_playlist_type", "event",
+		"-hls_segment_filename", filepath.Join(outDir, segmentPattern),
+		"-y",
+		filepath.Join(outDir, PlaylistName),
+	)
+	var stderr bytes.Buffer
+	j.cmd.Stderr = &stderr
+	if err := j.cmd.Start(); err != nil {
+		j.err = fmt.Errorf("error starting ffmpeg: %w", err)
+		close(j.done)
+		return j
+	}
+	log.Infof("Transcoding %s to HLS", srcPath)
+	t.jobs[hash] = j
+
+	go func() {
+		err := j.cmd.Wait()
+		t.mu.Lock()
+		delete(t.jobs, hash)
+		t.mu.Unlock()
+		if err != nil {
+			log.WithError(err).Errorf("Error transcoding %s\nstderr: %s", srcPath, stderr.String())
+			j.err = fmt.Errorf("error transcoding video: %w", err)
+			t.cache.Remove(hash)
+		} else {
+			log.Infof("Finished transcoding %s", srcPath)
+			if err := t.cache.Add(hash, t.running()...); err != nil {
+				log.WithError(err).Error("Error evicting transcode cache")
+			}
+		}
+		close(j.done)
+	}()
+	return j
+}
+
+func (t *Transcoder) running() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hashes := make([]string, 0, len(t.jobs))
+	for hash := range t.jobs {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+func (t *Transcoder) isComplete(playlistPath string) bool {
+	data, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(data, []byte("#EXT-X-ENDLIST"))
+}
+
+func hasSegment(playlistPath string) bool {
+	data, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(data, []byte("#EXTINF"))
+}
@@ -19,6 +19,7 @@ type MimeType string
 const (
 	MimeTypeImage MimeType = "image"
 	MimeTypeVideo MimeType = "video"
+	MimeTypeAudio MimeType = "audio"
 	MimeTypeOther MimeType = "other"
 	MimeTypeError MimeType = "error"
 )
@@ -26,3 +27,46 @@ const (
 func (mt MimeType) String() string {
 	return string(mt)
 }
+
+// Orientation is the EXIF orientation of an image, 0 when the image has none
+type Orientation uint32
+
+// SwapsDimensions tells whether the image is turned by a quarter when displayed
+func (o Orientation) SwapsDimensions() bool {
+	return o >= 5 && o <= 8
+}
+
+// NeedsAutoOrient tells whether the stored pixels differ from what should be displayed
+func (o Orientation) NeedsAutoOrient() bool {
+	return o > 1
+}
+
+// ThumbnailSize names one of the sizes thumbnails are stored in
+type ThumbnailSize string
+
+const (
+	// ThumbnailSmall fills the grid on phones
+	ThumbnailSmall ThumbnailSize = "small"
+	// ThumbnailLarge fills the grid on wide screens, it is MaxThumbnailSize
+	ThumbnailLarge ThumbnailSize = "large"
+	// ThumbnailPlaceholder is stretched in the lightbox while the full image loads
+	ThumbnailPlaceholder ThumbnailSize = "placeholder"
+)
+
+// ThumbnailSizes are the sizes every file gets a thumbnail in
+var ThumbnailSizes = []ThumbnailSize{ThumbnailSmall, ThumbnailLarge, ThumbnailPlaceholder}
+
+func (s ThumbnailSize) String() string {
+	return string(s)
+}
+
+// Pixels is the longest side of thumbnails of this size
+func (s ThumbnailSize) Pixels(maxThumbnailSize int) int {
+	switch s {
+	case ThumbnailSmall:
+		return maxThumbnailSize / 2
+	case ThumbnailPlaceholder:
+		return 64
+	}
+	return maxThumbnailSize
+}